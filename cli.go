@@ -1,145 +1,973 @@
-//go:build windows && cli
+//go:build cli
 
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"maps"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 )
 
+// Exit codes, see runCLI.
+const (
+	exitOK      = 0
+	exitFailure = 1 // e.g., nothing collected, or a report that can't be read
+	exitUsage   = 2 // unknown command, bad or conflicting flags, missing arguments
+	exitDiffers = 3 // diff found changes
+)
+
+// usageError
+// A bad command line, as opposed to a failure, see exitUsage.
+type usageError struct{ error }
+
+func usagef(format string, a ...any) error {
+	return usageError{fmt.Errorf(format, a...)}
+}
+
+// errBadFlags
+// Already reported by the flag package, along with the command's usage.
+var errBadFlags = errors.New("bad flags")
+
+// errDiffers
+// Not a failure, just what diff found, see exitDiffers.
+var errDiffers = errors.New("reports differ")
+
+// command
+// A CLI subcommand, with flags of its own.
+type command struct {
+	name  string
+	args  string // e.g., "OLD NEW"
+	about string
+	run   func(fs *flag.FlagSet, args []string) error
+}
+
+var commands []command
+
 func init() {
+	commands = []command{
+		{"collect", "", "Collect the report and print it (default).", cmdCollect},
+		{"render", "REPORT", "Print a saved JSON, YAML or TOML report (- for stdin) in another format.", cmdRender},
+		{"diff", "OLD NEW", "Compare two saved reports of the same machine.", cmdDiff},
+		{"merge", "REPORT|DIR...", "Merge saved reports into one inventory.", cmdMerge},
+		{"serve", "", "Serve the report over HTTP, collected on every request.", cmdServe},
+		{"version", "", "Print version.", cmdVersion},
+	}
+}
 
-	//****************************************************************************
-	// Usage Text
-	//****************************************************************************
+func main() {
+	os.Exit(runCLI(os.Args[1:]))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Commands
+////////////////////////////////////////////////////////////////////////////////
 
-	usageText := map[string][]string{
-		"header": {
-			"Winspecter - Win Specs Reporter",
-			"Options:",
-		},
-		"footer": {
-			"Notes:\n",
-			"  Use the launcher to generate HTML in current directory.",
-		},
+// runCLI
+// Run the command in args, collect if none is given, e.g., -json,
+// as the CLI did before commands, and return the exit code.
+func runCLI(args []string) int {
+	name := "collect"
+
+	switch {
+	case len(args) == 0:
+		// collect, as documented
+	case args[0] == "-version", args[0] == "--version":
+		name, args = "version", args[1:]
+	case args[0] == "-h", args[0] == "-help", args[0] == "--help", args[0] == "help":
+		usage()
+		return exitOK
+	case !strings.HasPrefix(args[0], "-"):
+		name, args = args[0], args[1:]
 	}
 
-	flag.Usage = func() {
-		for _, line := range usageText["header"] {
-			_, _ = fmt.Fprintf(flag.CommandLine.Output(), "%s\n\n", line)
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
 		}
+	}
+	if cmd == nil {
+		log.Printf("unknown command %q", name)
+		usage()
+		return exitUsage
+	}
 
-		flag.PrintDefaults()
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		_, _ = fmt.Fprintf(out, "Usage: winspecter-cli %s [options] %s\n\n", cmd.name, cmd.args)
+		_, _ = fmt.Fprintf(out, "%s\n\nOptions:\n\n", cmd.about)
+		fs.PrintDefaults()
+	}
 
-		_, _ = fmt.Fprintln(flag.CommandLine.Output(), "")
-		for _, line := range usageText["footer"] {
-			_, _ = fmt.Fprintln(flag.CommandLine.Output(), line)
-		}
+	err := cmd.run(fs, args)
+
+	var ue usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errDiffers):
+		return exitDiffers
+	case errors.Is(err, errBadFlags):
+		return exitUsage
+	case errors.As(err, &ue):
+		log.Print(err)
+		return exitUsage
+	default:
+		log.Print(err)
+		return exitFailure
 	}
+}
 
-	//****************************************************************************
-	// Options
-	//****************************************************************************
+func usage() {
+	out := flag.CommandLine.Output()
 
-	actions := map[string]*bool{
-		"json":   flag.Bool("json", false, "Print as JSON."),
-		"yaml":   flag.Bool("yaml", false, "Print as YAML."),
-		"toml":   flag.Bool("toml", false, "Print as TOML."),
-		"pretty": flag.Bool("pretty", false, "Pretty print (YAML-like)."),
-		"print":  flag.Bool("print", false, "Alias for pretty print."),
-		"flat":   flag.Bool("flat", false, "Print as flat list."),
-		"csv":    flag.Bool("csv", false, "Print as CSV."),
+	_, _ = fmt.Fprint(out, "Winspecter - Win Specs Reporter\n\n")
+	_, _ = fmt.Fprint(out, "Usage: winspecter-cli [command] [options] [arguments]\n\n")
+	_, _ = fmt.Fprint(out, "Commands:\n\n")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(out, "  %-10s%s\n", cmd.name, cmd.about)
+	}
+	_, _ = fmt.Fprint(out, "\n"+
+		"Run winspecter-cli COMMAND -h for its options.\n\n"+
+		"Exit codes: 0 success, 1 failure, 2 bad usage, 3 diff found changes.\n\n"+
+		"Notes:\n\n"+
+		"  The launcher writes the HTML report in the current directory and opens it,\n"+
+		"  unless winspecter.toml next to it says otherwise, e.g., OutputDir.\n")
+}
+
+// parse
+// Parse args into fs, expecting between min and max arguments, -1 for any.
+func parse(fs *flag.FlagSet, args []string, min, max int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errBadFlags
+	}
 
-		"vcsv": flag.Bool("vcsv", false,
-			"Print as vertical/transposed CSV "+
-				"(headers in rows, instead of single row)."),
+	switch n := fs.NArg(); {
+	case n < min:
+		return usagef("%s: missing arguments, expecting %s",
+			fs.Name(), commandArgs(fs.Name()))
+	case max >= 0 && n > max:
+		return usagef("%s: unexpected arguments %q", fs.Name(), fs.Args()[max:])
+	}
+	return nil
+}
 
-		"version": flag.Bool("version", false, "Print version."),
+func commandArgs(name string) string {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.args
+		}
 	}
+	return ""
+}
 
-	// CSV and VCSV flags
-	quote := flag.String("quote", `"`, "Quote string for CSV.")
-	delim := flag.String("delim", `,`, "CSV and VCSV column delimiter.")
+func cmdCollect(fs *flag.FlagSet, args []string) error {
+	format := addFormatFlags(fs, "pretty", "json", "yaml", "toml", "pretty", "print", "flat", "csv", "vcsv", "html").withTemplate(fs)
+	csvFlags := addCSVFlags(fs)
+	units := addUnitsFlag(fs)
+	sections := addSectionFlags(fs)
+	collector := addCollectFlags(fs, true)
+	var out outputs
+	fs.Var(&out, "o", outputUsage)
+	fileFlags := addFileFlags(fs)
 
-	// All format flags
-	withKey := flag.Bool("key", false, "Include Windows product key.")
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
 
-	//****************************************************************************
-	// Parse Args
-	//****************************************************************************
+	f, err := format.get(fs)
+	if err != nil {
+		return err
+	}
+	if err := out.resolve(f, format.given); err != nil {
+		return err
+	}
+	files, err := fileFlags.options()
+	if err != nil {
+		return err
+	}
+	csvOpts, err := csvFlags.options()
+	if err != nil {
+		return err
+	}
+	if err := units.set(); err != nil {
+		return err
+	}
+	keys, err := sections.keys()
+	if err != nil {
+		return err
+	}
+	opts := renderOptions{CSV: csvOpts, Template: format.template}
 
-	flag.Parse()
+	// Ctrl+C stops collecting, but still prints what's been collected.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	var selectedAction string
-	for action, selected := range actions {
-		if *selected {
-			selectedAction = action
-			break
-		}
+	s, err := collector.collect(ctx, keys)
+	if err != nil {
+		return err
 	}
 
-	switch {
+	return out.write(s, opts, files)
+}
+
+func cmdRender(fs *flag.FlagSet, args []string) error {
+	format := addFormatFlags(fs, "pretty", "json", "yaml", "toml", "pretty", "print", "flat", "csv", "vcsv", "html").withTemplate(fs)
+	csvFlags := addCSVFlags(fs)
+	units := addUnitsFlag(fs)
+	sections := addSectionFlags(fs)
+	var out outputs
+	fs.Var(&out, "o", outputUsage)
+	fileFlags := addFileFlags(fs)
 
-	// Handle absent and "naked" or invalid args
-	case selectedAction == "", len(flag.Args()) > 0:
-		flag.Usage()
-		os.Exit(1)
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
 
-	// Print version
-	case selectedAction == "version":
-		fmt.Printf("Winspecter v%s\n", Version)
-		return
+	f, err := format.get(fs)
+	if err != nil {
+		return err
+	}
+	if err := out.resolve(f, format.given); err != nil {
+		return err
+	}
+	files, err := fileFlags.options()
+	if err != nil {
+		return err
+	}
+	csvOpts, err := csvFlags.options()
+	if err != nil {
+		return err
+	}
+	if err := units.set(); err != nil {
+		return err
+	}
+	keys, err := sections.keys()
+	if err != nil {
+		return err
 	}
 
+	opts := renderOptions{CSV: csvOpts, Template: format.template}
+
 	var s Specs
-	if err := s.Collect(); err != nil {
-		log.Fatal(err)
+	if err := s.LoadFile(fs.Arg(0)); err != nil {
+		return err
+	}
+	s.keep(keys)
+
+	return out.write(&s, opts, files)
+}
+
+func cmdDiff(fs *flag.FlagSet, args []string) error {
+	format := addFormatFlags(fs, "pretty", "pretty", "json", "html")
+	units := addUnitsFlag(fs)
+	sections := addSectionFlags(fs)
+	volatile := fs.Bool("volatile", false,
+		"Compare values that change on their own between runs as well, "+
+			"i.e., disk wear, temperature, power-on hours, uncorrected errors and volume free space.")
+
+	if err := parse(fs, args, 2, 2); err != nil {
+		return err
 	}
 
-	if *withKey {
-		if err := s.CollectProductKey(); err != nil {
-			log.Fatal(err)
+	f, err := format.get(fs)
+	if err != nil {
+		return err
+	}
+	if err := units.set(); err != nil {
+		return err
+	}
+	keys, err := sections.keys()
+	if err != nil {
+		return err
+	}
+
+	var old, new Specs
+	for i, s := range []*Specs{&old, &new} {
+		if err := s.LoadFile(fs.Arg(i)); err != nil {
+			return err
 		}
+		s.keep(keys)
+	}
+
+	z := Diff(&old, &new, DiffOptions{Volatile: *volatile})
+
+	var res string
+	switch f {
+	case "json":
+		res, err = z.JSON()
+		res += "\n"
+	case "html":
+		res, err = z.HTML(fs.Arg(0), fs.Arg(1))
+	default:
+		res = z.Text()
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Print(res)
+
+	if len(z) > 0 {
+		return errDiffers
+	}
+	return nil
+}
+
+func cmdMerge(fs *flag.FlagSet, args []string) error {
+	format := addFormatFlags(fs, "csv", "json", "csv", "html")
+	csvFlags := addCSVFlags(fs)
+	units := addUnitsFlag(fs)
+	sections := addSectionFlags(fs)
+
+	if err := parse(fs, args, 1, -1); err != nil {
+		return err
+	}
+
+	f, err := format.get(fs)
+	if err != nil {
+		return err
+	}
+	csvOpts, err := csvFlags.options()
+	if err != nil {
+		return err
+	}
+	if err := units.set(); err != nil {
+		return err
+	}
+	keys, err := sections.keys()
+	if err != nil {
+		return err
+	}
+
+	// Unreadable reports are left out, not worth giving up on the others.
+	snaps, err := LoadSnapshots(fs.Args())
+	if err != nil {
+		log.Print(err)
+	}
+	if len(snaps) == 0 {
+		return fmt.Errorf("no report to merge")
 	}
 
-	switch selectedAction {
+	snaps = Dedup(snaps)
+	for _, snap := range snaps {
+		snap.Specs.keep(keys)
+	}
+
+	var res string
+	switch f {
 	case "json":
-		res, err := s.JSON()
+		res, err = MergeJSON(snaps)
+		res += "\n"
+	case "html":
+		res, err = MergeHTML(snaps)
+	default:
+		res, err = MergeCSV(snaps, csvOpts)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Print(res)
+
+	return nil
+}
+
+// serveFormats
+// Content types of the formats served, see cmdServe.
+var serveFormats = map[string]string{
+	"json":   "application/json",
+	"yaml":   "application/yaml",
+	"toml":   "application/toml",
+	"pretty": "text/plain; charset=utf-8",
+	"flat":   "text/plain; charset=utf-8",
+	"csv":    "text/csv; charset=utf-8",
+	"vcsv":   "text/csv; charset=utf-8",
+	"html":   "text/html; charset=utf-8",
+}
+
+func cmdServe(fs *flag.FlagSet, args []string) error {
+	addr := fs.String("addr", "127.0.0.1:8080",
+		"Address to listen on, e.g., :8080 for every interface.")
+	csvFlags := addCSVFlags(fs)
+	units := addUnitsFlag(fs)
+	sections := addSectionFlags(fs)
+	collector := addCollectFlags(fs, false)
+
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	csvOpts, err := csvFlags.options()
+	if err != nil {
+		return err
+	}
+	if err := units.set(); err != nil {
+		return err
+	}
+	keys, err := sections.keys()
+	if err != nil {
+		return err
+	}
+
+	// Plain HTTP, anyone on the way would read it.
+	if *collector.withKey && !isLoopback(*addr) {
+		return usagef("serve: -key is only allowed on a loopback address, e.g., 127.0.0.1:8080, not %s", *addr)
+	}
+
+	// One collection at a time, WMI doesn't like more.
+	var mu sync.Mutex
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintf(w, "Winspecter v%s\n\n", Version)
+		for _, f := range []string{"html", "json", "yaml", "toml", "pretty", "flat", "csv", "vcsv"} {
+			_, _ = fmt.Fprintf(w, "/report/%s\n", f)
+		}
+	})
+	mux.HandleFunc("GET /report/{format}", func(w http.ResponseWriter, r *http.Request) {
+		f := r.PathValue("format")
+		ctype, ok := serveFormats[f]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		mu.Lock()
+		s, err := collector.collect(r.Context(), keys)
+		mu.Unlock()
 		if err != nil {
-			log.Fatal(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-		fmt.Println(res)
 
-	case "yaml":
-		res, err := s.YAML()
+		res, err := render(s, f, renderOptions{CSV: csvOpts})
 		if err != nil {
-			log.Fatal(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-		fmt.Println(res)
 
+		w.Header().Set("Content-Type", ctype)
+		_, _ = fmt.Fprint(w, res)
+	})
+
+	log.Printf("serving on http://%s/", *addr)
+	return http.ListenAndServe(*addr, mux)
+}
+
+// isLoopback
+// Whether addr, as in -addr, only listens on a loopback interface,
+// an empty host being every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func cmdVersion(fs *flag.FlagSet, args []string) error {
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	fmt.Printf("Winspecter v%s\n", Version)
+	return nil
+}
+
+// renderOptions
+// What formats are rendered with, on top of the format itself.
+type renderOptions struct {
+	CSV      CSVOptions
+	Template *template.Template // see formatFlags.withTemplate
+}
+
+// render
+// s as the given format, see -format.
+func render(s *Specs, format string, opts renderOptions) (string, error) {
+	var res string
+	var err error
+
+	switch format {
+	case "json":
+		res, err = s.JSON()
+		res += "\n"
+	case "yaml":
+		res, err = s.YAML()
+		res += "\n"
 	case "toml":
-		res, err := s.TOML()
+		res, err = s.TOML()
+		res += "\n"
+	case "pretty", "print":
+		res = s.TextPretty(": ") + "\n"
+	case "flat":
+		res = s.TextFlat(": ") + "\n"
+	case "csv":
+		res, err = s.TextCSV(opts.CSV)
+	case "vcsv":
+		res, err = s.TextVCSV(opts.CSV)
+	case "html":
+		res, err = s.genHTMLFull(time.Now())
+	case "template":
+		res, err = s.TextTemplate(opts.Template)
+	default:
+		err = fmt.Errorf("unknown format %q", format)
+	}
+
+	return res, err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Flags shared by commands
+////////////////////////////////////////////////////////////////////////////////
+
+// outputFormats
+// Formats of -o files, by extension.
+var outputFormats = map[string]string{
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+	".txt":  "pretty",
+	".csv":  "csv",
+	".html": "html",
+	".htm":  "html",
+}
+
+const outputUsage = "Write to this file instead of stdout, " +
+	"as .json, .yaml, .toml, .txt (pretty), .csv or .html by its extension, " +
+	"or - for stdout as -format. Repeat for several formats out of a single run. " +
+	"{user}, {host} and {time} are filled in, e.g., reports/{host}_{time}.html, " +
+	"and so are text/template fields, e.g., {{.System.SKU}}."
+
+// outputs
+// -o, where to write what, see outputFormats.
+// Without any, it's stdout as -format.
+type outputs []output
+
+type output struct{ path, format string }
+
+func (o *outputs) String() string {
+	var paths []string
+	for _, v := range *o {
+		paths = append(paths, v.path)
+	}
+	return strings.Join(paths, ", ")
+}
+
+func (o *outputs) Set(path string) error {
+	*o = append(*o, output{path: path})
+	return nil
+}
+
+// resolve
+// The format of every output, checked before collecting anything.
+// A format asked for, e.g., -json, is only for stdout, so there has to be one.
+func (o *outputs) resolve(format string, given bool) error {
+	switch {
+	case len(*o) == 0:
+		*o = outputs{{path: "-"}}
+	case given && !slices.ContainsFunc(*o, func(v output) bool { return v.path == "-" }):
+		return usagef("-%s is only for stdout, add -o - or drop it, "+
+			"files are written as their extension says", format)
+	}
+
+	for i, v := range *o {
+		if v.path == "-" {
+			(*o)[i].format = format
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(v.path))
+		f, ok := outputFormats[ext]
+		if !ok {
+			return usagef("can't tell the format of %q, expecting one of %s",
+				v.path, strings.Join(slices.Sorted(maps.Keys(outputFormats)), ", "))
+		}
+		(*o)[i].format = f
+	}
+
+	return nil
+}
+
+// write
+// Render s once per output. Failing ones don't stop the others.
+func (o outputs) write(s *Specs, opts renderOptions, files fileOptions) error {
+	var errs []error
+	at := time.Now()
+
+	// Owner only, if there's the product key in it, and kept apart if so asked.
+	mode, shared, keyDir := os.FileMode(0644), true, ""
+	if s.HasProductKey() {
+		mode, shared, keyDir = DefaultReportMode, false, files.keyDir
+	}
+	if files.mode != 0 {
+		mode = files.mode
+	}
+	if keyDir != "" && slices.ContainsFunc(o, func(v output) bool { return v.path != "-" }) {
+		if err := makeOwnerDir(keyDir); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range o {
+		res, err := render(s, v.format, opts)
 		if err != nil {
-			log.Fatal(err)
+			errs = append(errs, fmt.Errorf("%s: %w", v.path, err))
+			continue
 		}
-		fmt.Println(res)
 
-	case "pretty", "print":
-		fmt.Println(s.TextPretty(": "))
+		if v.path == "-" {
+			fmt.Print(res)
+			continue
+		}
+		path, err := s.ExpandName(v.path, at)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if keyDir != "" {
+			path = filepath.Join(keyDir, filepath.Base(path))
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := writeReportFile(path, []byte(res), mode, shared); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
-	case "flat":
-		fmt.Println(s.TextFlat(": "))
+	return errors.Join(errs...)
+}
 
-	case "csv":
-		fmt.Println(s.TextCSV(*delim, *quote))
+// formatFlags
+// -format, and a shorthand flag per format, e.g., -json,
+// as the CLI had before commands.
+type formatFlags struct {
+	format  *string
+	def     string
+	allowed []string
+	given   bool // any of them, see get
 
-	case "vcsv":
-		fmt.Println(s.TextVCSV(*delim, *quote))
+	tmpl     *string            // see withTemplate
+	template *template.Template // parsed by get
+}
 
-	// Just in case
-	default:
-		flag.Usage()
+func addFormatFlags(fs *flag.FlagSet, def string, allowed ...string) *formatFlags {
+	f := &formatFlags{def: def, allowed: allowed}
+
+	f.format = fs.String("format", def,
+		"Output format, one of "+strings.Join(allowed, ", ")+".")
+	for _, v := range allowed {
+		fs.Bool(v, false, "Short for -format "+v+".")
+	}
+
+	return f
+}
+
+// withTemplate
+// -template too, a format of its own, for stdout.
+func (f *formatFlags) withTemplate(fs *flag.FlagSet) *formatFlags {
+	f.tmpl = fs.String("template", "",
+		"Render through this Go text/template file instead of -format, "+
+			"or a built-in one: "+strings.Join(BuiltinTemplates(), ", ")+".")
+	return f
+}
+
+// get
+// The one format asked for. Conflicting ones are an error,
+// e.g., -json -yaml, or -format csv -json, whatever their order.
+// -template is parsed here, before collecting anything.
+func (f *formatFlags) get(fs *flag.FlagSet) (string, error) {
+	format, err := f.getFormat(fs)
+	if err != nil || f.tmpl == nil || *f.tmpl == "" {
+		return format, err
+	}
+
+	if f.given {
+		return "", usagef("conflicting formats -template and -%s", format)
+	}
+	if f.template, err = ParseReportTemplate(*f.tmpl); err != nil {
+		return "", err
+	}
+	f.given = true
+	return "template", nil
+}
+
+func (f *formatFlags) getFormat(fs *flag.FlagSet) (string, error) {
+	var given []string
+	picked := map[string]bool{}
+
+	// In lexical order
+	fs.Visit(func(fl *flag.Flag) {
+		switch {
+		case fl.Name == "format":
+			given = append(given, "-format "+*f.format)
+			picked[canonicalFormat(*f.format)] = true
+		case slices.Contains(f.allowed, fl.Name) && fl.Value.String() == "true":
+			given = append(given, "-"+fl.Name)
+			picked[canonicalFormat(fl.Name)] = true
+		}
+	})
+
+	f.given = len(picked) > 0
+
+	switch {
+	case len(picked) > 1:
+		return "", usagef("conflicting formats %s", strings.Join(given, ", "))
+	case len(picked) == 0:
+		return f.def, nil
 	}
+
+	for k := range picked {
+		if !slices.Contains(f.allowed, k) {
+			return "", usagef("unknown format %q, expecting one of %s",
+				k, strings.Join(f.allowed, ", "))
+		}
+		return k, nil
+	}
+	return f.def, nil
+}
+
+func canonicalFormat(f string) string {
+	f = strings.ToLower(f)
+	if f == "print" {
+		return "pretty"
+	}
+	return f
+}
+
+type csvFlags struct {
+	quote, delim, eol, layout, limits *string
+	bom, header                       *bool
+}
+
+func addCSVFlags(fs *flag.FlagSet) *csvFlags {
+	return &csvFlags{
+		quote: fs.String("quote", `"`,
+			"Quote string for CSV, only \" is supported (RFC 4180)."),
+		delim: fs.String("delim", `,`,
+			"CSV and VCSV column delimiter, a single character, \\t for tab."),
+		bom: fs.Bool("bom", false,
+			"Start CSV and VCSV with a UTF-8 BOM, for Excel."),
+		eol: fs.String("eol", "lf",
+			"CSV and VCSV line endings, lf or crlf."),
+		header: fs.Bool("header", true,
+			"Include CSV and VCSV headers, -header=false to append to an existing file."),
+		layout: fs.String("layout", "wide",
+			"CSV and VCSV layout of CPUs, DIMMs, disks, etc.: "+
+				"wide (as many columns as there are), "+
+				"fixed (as many columns as -max, for every machine alike), "+
+				"or long (CSV only, a row per component)."),
+		limits: fs.String("max", strconv.Itoa(DefaultCSVLimit),
+			"How many of each for -layout fixed, "+
+				"optionally per group, e.g., 4,DIMMs=8,Disks=6."),
+	}
+}
+
+func (f *csvFlags) options() (CSVOptions, error) {
+	comma, err := ParseCSVComma(*f.delim)
+	if err != nil {
+		return CSVOptions{}, usageError{err}
+	}
+	if *f.quote != `"` {
+		return CSVOptions{}, usagef("invalid CSV quote %q, only %q is supported", *f.quote, `"`)
+	}
+	if *f.eol != "lf" && *f.eol != "crlf" {
+		return CSVOptions{}, usagef("invalid line endings %q, expecting lf or crlf", *f.eol)
+	}
+	layout, err := ParseCSVLayout(*f.layout)
+	if err != nil {
+		return CSVOptions{}, usageError{err}
+	}
+	limits, err := ParseCSVLimits(*f.limits)
+	if err != nil {
+		return CSVOptions{}, usageError{err}
+	}
+
+	return CSVOptions{
+		Comma:    comma,
+		CRLF:     *f.eol == "crlf",
+		BOM:      *f.bom,
+		NoHeader: !*f.header,
+		Layout:   layout,
+		Limits:   limits,
+	}, nil
+}
+
+type unitsFlag struct{ units *string }
+
+func addUnitsFlag(fs *flag.FlagSet) unitsFlag {
+	return unitsFlag{fs.String("units", "",
+		"Write sizes and speeds as raw (exact bytes and kHz), "+
+			"si or iec (fractional GB or GiB, MHz, etc.), "+
+			"or human (e.g., \"15.9 GiB\") "+
+			"(default whole GiB for memory, GB for disks, MiB for caches, GHz).")}
+}
+
+// set
+// OutputUnits, package-wide, see Units.
+func (f unitsFlag) set() (err error) {
+	if OutputUnits, err = ParseUnits(*f.units); err != nil {
+		return usageError{err}
+	}
+	return nil
+}
+
+// fileFlags
+// Who gets to read the files -o writes.
+type fileFlags struct{ mode, keyDir *string }
+
+type fileOptions struct {
+	mode   os.FileMode // 0 for 0644, or DefaultReportMode with the product key
+	keyDir string      // for files with the product key, instead of where -o says
+}
+
+func addFileFlags(fs *flag.FlagSet) fileFlags {
+	return fileFlags{
+		mode: fs.String("mode", "",
+			"Octal mode of the files written, e.g., 0640, but for the owner's bits only "+
+				"with the product key (default 0644, or 0600 with the product key). "+
+				"On Windows, it only tells apart read-only files, e.g., 0400."),
+		keyDir: fs.String("keydir", "",
+			"Write files with the product key into this directory instead, "+
+				"created if missing, owner only."),
+	}
+}
+
+func (f fileFlags) options() (fileOptions, error) {
+	o := fileOptions{keyDir: *f.keyDir}
+	if *f.mode != "" {
+		m, err := ParseFileMode(*f.mode)
+		if err != nil {
+			return o, usageError{err}
+		}
+		o.mode = m
+	}
+	return o, nil
+}
+
+type sectionFlags struct{ include, exclude *string }
+
+func addSectionFlags(fs *flag.FlagSet) sectionFlags {
+	return sectionFlags{
+		include: fs.String("sections", "",
+			"Only these comma-separated sections, e.g., cpu,memory,disks, "+
+				"out of "+strings.Join(SectionKeys(), ", ")+" (default all)."),
+		exclude: fs.String("exclude", "",
+			"Leave out these comma-separated sections."),
+	}
+}
+
+func (f sectionFlags) keys() ([]string, error) {
+	keys, err := ParseSections(*f.include, *f.exclude)
+	if err != nil {
+		return nil, usageError{err}
+	}
+	return keys, nil
+}
+
+// collectFlags
+// How reports are collected, by collect and serve.
+type collectFlags struct {
+	withKey        *bool
+	record, replay *string
+	timeout        *time.Duration
+	sectionTimeout *time.Duration
+	failFast       *bool
+}
+
+// addCollectFlags
+// With record, -record is there, too, which serve has no use for.
+func addCollectFlags(fs *flag.FlagSet, record bool) *collectFlags {
+	f := &collectFlags{
+		withKey: fs.Bool("key", false, "Include Windows product key."),
+		replay: fs.String("replay", "",
+			"Read WMI results and registry values from fixtures "+
+				"in the given directory, instead of the live system."),
+		timeout: fs.Duration("timeout", 0,
+			"Give up on whatever is still being collected after this long, "+
+				"e.g., 30s (default no limit)."),
+		sectionTimeout: fs.Duration("section-timeout", 0,
+			"Give up on a single section after this long "+
+				"(default "+DefaultSectionTimeout.String()+", unless the section says otherwise)."),
+		failFast: fs.Bool("fail-fast", false,
+			"Stop collecting on the first failing section, "+
+				"instead of reporting it and carrying on."),
+	}
+
+	f.record = new(string)
+	if record {
+		fs.StringVar(f.record, "record", "",
+			"Dump raw WMI results and registry values as fixtures "+
+				"into the given directory.")
+	}
+
+	return f
+}
+
+// collect
+// A report from the live system, or fixtures, see -record and -replay.
+func (f *collectFlags) collect(ctx context.Context, keys []string) (*Specs, error) {
+	if *f.record != "" && *f.replay != "" {
+		return nil, usagef("conflicting options -record and -replay")
+	}
+
+	src := DefaultSources()
+	save := func() error { return nil }
+
+	switch {
+	case *f.replay != "":
+		var err error
+		if src, err = ReplaySources(*f.replay); err != nil {
+			return nil, err
+		}
+	case *f.record != "":
+		src, save = RecordSources(src, *f.record)
+	}
+
+	if *f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *f.timeout)
+		defer cancel()
+	}
+
+	// Part of Windows, skipped along with it.
+	if *f.withKey {
+		keys = append(slices.Clip(keys), "key")
+	}
+
+	var s Specs
+	err := s.Collect(ctx, src, CollectOptions{
+		Sections:       keys,
+		SectionTimeout: *f.sectionTimeout,
+		FailFast:       *f.failFast,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, save()
 }
@@ -0,0 +1,104 @@
+//go:build cli
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestIsLoopback(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8080":  true,
+		"127.1.2.3:8080":  true,
+		"[::1]:8080":      true,
+		"LocalHost:8080":  true,
+		":8080":           false, // every interface
+		"0.0.0.0:8080":    false,
+		"[::]:8080":       false,
+		"192.168.1.5:80":  false,
+		"myhost:8080":     false,
+		"127.0.0.1":       false, // no port, not an address
+		"localhost.evil:": false,
+	} {
+		if got := isLoopback(addr); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+// -mode and -keydir, with and without the product key.
+func TestOutputsWrite(t *testing.T) {
+	src, err := ReplaySources("testdata/replay/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name  string
+		key   bool
+		files fileOptions
+		dir   string // where the file ends up, under the test's directory
+		mode  os.FileMode
+	}{
+		{"default", false, fileOptions{}, "out", 0644},
+		{"default with the key", true, fileOptions{}, "out", DefaultReportMode},
+		{"mode", false, fileOptions{mode: 0640}, "out", 0640},
+		{"mode with the key", true, fileOptions{mode: 0640}, "out", 0600},
+		{"keydir", false, fileOptions{keyDir: "keys"}, "out", 0644},
+		{"keydir with the key", true, fileOptions{keyDir: "keys"}, "keys", DefaultReportMode},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			sections := []string{"windows"}
+			if c.key {
+				sections = append(sections, "key")
+			}
+			var s Specs
+			if err := s.Collect(context.Background(), src, CollectOptions{Sections: sections}); err != nil {
+				t.Fatal(err)
+			}
+			if c.key {
+				s.node("windows").(*Windows).OriginalProductKey = "XXXXX-XXXXX-XXXXX-XXXXX-XXXXX"
+			}
+
+			dir := t.TempDir()
+			if c.files.keyDir != "" {
+				c.files.keyDir = filepath.Join(dir, c.files.keyDir)
+			}
+			o := outputs{{path: filepath.Join(dir, "out", "{host}.json"), format: "json"}}
+			if err := o.write(&s, renderOptions{}, c.files); err != nil {
+				t.Fatal(err)
+			}
+
+			fi, err := os.Stat(filepath.Join(dir, c.dir, "AUDIT-PC01.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runtime.GOOS != "windows" && fi.Mode().Perm() != c.mode {
+				t.Errorf("mode %v, want %v", fi.Mode().Perm(), c.mode)
+			}
+		})
+	}
+}
+
+// collect is the default command, and sections it can't collect are bad usage.
+func TestRunCLISections(t *testing.T) {
+	dir := t.TempDir()
+	for _, c := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"-sections", "key"}, exitUsage},
+		{[]string{"-sections", "windows,key", "-exclude", "windows"}, exitUsage},
+		{[]string{"-sections", "windows,key"}, exitOK},
+		{[]string{"collect", "-sections", "key,windows"}, exitOK},
+	} {
+		args := append(c.args, "-replay", "testdata/replay/windows", "-o", filepath.Join(dir, "{host}.json"))
+		if got := runCLI(args); got != c.want {
+			t.Errorf("%q exits %d, want %d", c.args, got, c.want)
+		}
+	}
+}
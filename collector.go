@@ -1,35 +1,27 @@
-//go:build windows
-
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
 	"os/user"
 	"strings"
 	"time"
 
-	"github.com/yusufpapurcu/wmi"
 	"golang.org/x/sync/errgroup"
-	"golang.org/x/sys/windows/registry"
-	//"github.com/StackExchange/wmi"
-	//"github.com/microsoft/wmi/go/wmi"
 )
 
 // Specs
-// Field order matters here.
-// Embedded type names don't get processed as parent when being marshaled.
-// These tags override it.
+// The collected sections by key, see Section and RegisterSection,
+// reported in their order, then whatever went wrong collecting them.
+// Sections left out of collection, see CollectOptions.Sections,
+// are omitted from every output format.
 type Specs struct {
-	CurrentUser `json:"CurrentUser" yaml:"currentuser" toml:"CurrentUser"`
-	Windows     `json:"Windows"     yaml:"windows"     toml:"Windows"`
-	System      `json:"System"      yaml:"system"      toml:"System"`
-	Baseboard   `json:"Baseboard"   yaml:"baseboard"   toml:"Baseboard"`
-	BIOS        `json:"BIOS"        yaml:"bios"        toml:"BIOS"`
-	CPUs        `json:"CPUs"        yaml:"cpus"        toml:"CPUs"`
-	GPUs        `json:"GPUs"        yaml:"gpus"        toml:"GPUs"`
-	Memory      `json:"Memory"      yaml:"memory"      toml:"Memory"`
-	Disks       `json:"Disks"       yaml:"disks"       toml:"Disks"`
-	NetAdapters `json:"NetAdapters" yaml:"netadapters" toml:"NetAdapters"`
+	nodes     map[string]any
+	collected time.Time // when Collect ran, zero if a loaded report doesn't say
+	units     Units     // a loaded report's, see Diff, raw when collected, i.e., exact
+	Diagnostics
 }
 
 // Windows
@@ -70,8 +62,10 @@ type L2CacheSize uint64
 type L3CacheSize uint64
 
 type Memory struct {
-	TotalSize DIMMCapacity
-	TotalSlot uint64
+	TotalSize   DIMMCapacity
+	MaxCapacity DIMMCapacity
+	TotalSlot   uint64
+	UsedSlot    uint64
 	DIMMs
 }
 
@@ -101,10 +95,21 @@ type Disks []Disk
 
 type Disk struct {
 	//Manufacturer string // not informative
-	Model        string
-	Size         DiskSize
-	SerialNumber string
-	Status       string
+	Model             string
+	Size              DiskSize
+	SerialNumber      string
+	Status            string
+	MediaType         string // HDD, SSD, etc.
+	BusType           string // NVMe, SATA, USB, etc.
+	FirmwareVersion   string
+	HealthStatus      string // Healthy, Warning or Unhealthy, as Windows judges
+	Wear              string `diff:"volatile"` // % of rated endurance used, SSDs only
+	Temperature       string `diff:"volatile"`
+	PowerOnHours      string `diff:"volatile"`
+	UncorrectedErrors string `diff:"volatile"` // read and write
+	SMARTWarning      string `diff:"volatile"` // why the disk may be failing, None if nothing says so
+	PartitionStyle    string // GPT or MBR
+	Volumes
 }
 
 type DiskSize uint64
@@ -125,6 +130,47 @@ type BBS struct {
 	System
 }
 
+// Read together, but reported separately.
+var (
+	_ = RegisterSection(Section{
+		Name: "BIOS/Baseboard/System", Key: "bbs", Order: 2,
+		Deps:   []string{"smbios"},
+		Hidden: true,
+		New:    func() any { return &BBS{} },
+		Collect: func(_ context.Context, src Sources, s *Specs, node any) error {
+			src.smbios = s.smbios()
+			return node.(*BBS).collect(src)
+		},
+	})
+	_ = RegisterSection(Section{
+		Name: "System", Key: "system", Order: 30,
+		Deps: []string{"bbs"},
+		New:  func() any { return &System{} },
+		Collect: func(_ context.Context, _ Sources, s *Specs, node any) error {
+			*node.(*System) = s.node("bbs").(*BBS).System
+			return nil
+		},
+	})
+	_ = RegisterSection(Section{
+		Name: "Baseboard", Key: "baseboard", Order: 40,
+		Deps: []string{"bbs"},
+		New:  func() any { return &Baseboard{} },
+		Collect: func(_ context.Context, _ Sources, s *Specs, node any) error {
+			*node.(*Baseboard) = s.node("bbs").(*BBS).Baseboard
+			return nil
+		},
+	})
+	_ = RegisterSection(Section{
+		Name: "BIOS", Key: "bios", Order: 50,
+		Deps: []string{"bbs"},
+		New:  func() any { return &BIOS{} },
+		Collect: func(_ context.Context, _ Sources, s *Specs, node any) error {
+			*node.(*BIOS) = s.node("bbs").(*BBS).BIOS
+			return nil
+		},
+	})
+)
+
 type BIOS struct {
 	Vendor      string
 	Version     string
@@ -143,8 +189,12 @@ type System struct {
 	Version      string
 	ProductName  string
 	SKU          string
+	UUID         string
+	ChassisType  ChassisType
 }
 
+type ChassisType uint64
+
 type NetAdapters []NetAdapter
 
 type NetAdapter struct {
@@ -153,136 +203,170 @@ type NetAdapter struct {
 	Manufacturer string
 }
 
-const wmiTimeout = 5 * time.Second
-
 ////////////////////////////////////////////////////////////////////////////////
 // Public Methods
 ////////////////////////////////////////////////////////////////////////////////
 
-func (s *Specs) Collect() (err error) {
-	bbs := &BBS{}
-	ctx := context.Background()
-	g, _ := errgroup.WithContext(ctx)
-
-	g.Go(func() error {
-		return s.Windows.collect()
-	})
-	g.Go(func() error {
-		return s.CurrentUser.collect()
-	})
-	g.Go(func() error {
-		return s.CPUs.collect()
-	})
-	g.Go(func() error {
-		return s.GPUs.collect()
-	})
-	g.Go(func() error {
-		return s.Memory.collect()
-	})
-	g.Go(func() error {
-		return s.Disks.collect()
-	})
-	g.Go(func() error {
-		return s.NetAdapters.collect()
-	})
-	g.Go(func() error {
-		return bbs.collect()
-	})
+// CollectOptions
+// Zero value is usable: every default section, own time limits, tolerant.
+type CollectOptions struct {
+	// How long a single section may take, 0 means each section's own limit,
+	// see Section.Timeout.
+	// The overall deadline, if any, comes from the context given to Collect.
+	SectionTimeout time.Duration
+
+	// Stop every other section on the first failing one,
+	// instead of reporting it and carrying on.
+	// Warnings, i.e., values reported as N/A, never stop anything.
+	FailFast bool
+
+	// Which sections to collect, see SectionKeys and ParseSections.
+	// Empty means every section but the optional ones.
+	Sections []string
+}
 
-	if err := g.Wait(); err != nil {
-		return err
+const DefaultSectionTimeout = 10 * time.Second
+
+// Collect
+// Gather every section from src, see Sources, as soon as its Deps are done.
+// A failing section doesn't stop the others unless opts.FailFast,
+// its error is recorded in Diagnostics instead,
+// and so are sections that timed out or were canceled, see Diagnostics.TimedOut.
+// Only when every reported section fails, itself or by a failing dependency,
+// or on the first failure with opts.FailFast, is an error returned.
+func (s *Specs) Collect(ctx context.Context, src Sources, opts CollectOptions) error {
+	run := resolveSections(opts.Sections)
+	s.collected, s.units = time.Now(), UnitsRaw
+
+	// Every node exists before anything runs,
+	// so collectors only ever read the map.
+	s.nodes = map[string]any{}
+	done := map[string]chan struct{}{}
+	for _, sec := range run {
+		if sec.New != nil {
+			s.nodes[sec.Key] = sec.New()
+		}
+		done[sec.Key] = make(chan struct{})
 	}
 
-	s.BIOS, s.Baseboard, s.System = bbs.BIOS, bbs.Baseboard, bbs.System
+	errs := make([]error, len(run))
 
-	return nil
-}
+	g, gctx := errgroup.WithContext(ctx)
+	for i, sec := range run {
+		g.Go(func() error {
+			defer close(done[sec.Key])
 
-func (w *Windows) CollectProductKey() error {
-	var k []struct {
-		OA3xOriginalProductKey string
+			for _, d := range sec.Deps {
+				select {
+				case <-done[d]:
+				case <-gctx.Done():
+				}
+			}
+
+			timeout := cmp.Or(opts.SectionTimeout, sec.Timeout, DefaultSectionTimeout)
+			sctx, cancel := context.WithTimeout(gctx, timeout)
+			defer cancel()
+
+			if err := sctx.Err(); err != nil {
+				errs[i] = err
+				return nil
+			}
+
+			errs[i] = sec.Collect(sctx, src, s, s.nodes[sec.Key])
+
+			// Only the first failure cancels gctx, hence the siblings.
+			if opts.FailFast && errs[i] != nil && !isWarning(errs[i]) {
+				return fmt.Errorf("%s: %w", sec.Name, errs[i])
+			}
+			return nil
+		})
 	}
+	fatal := g.Wait()
 
-	ctx, cancel := context.WithTimeout(context.Background(), wmiTimeout)
-	defer cancel()
+	// In section order, regardless of which finished first.
+	failed := map[string]bool{}
+	reported, reportedFailed := 0, 0
+	for i, sec := range run {
+		s.Diagnostics.add(sec.Name, errs[i])
 
-	done := make(chan error, 1)
-	go func() {
-		done <- wmi.Query(
-			"SELECT OA3xOriginalProductKey FROM SoftwareLicensingService",
-			&k)
-	}()
+		failed[sec.Key] = errs[i] != nil && !isWarning(errs[i])
+		for _, d := range sec.Deps {
+			failed[sec.Key] = failed[sec.Key] || failed[d]
+		}
 
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-done:
-		if err != nil {
-			return err
+		if !sec.Hidden {
+			reported++
+			if failed[sec.Key] {
+				reportedFailed++
+			}
 		}
 	}
 
-	if k[0].OA3xOriginalProductKey != "" {
-		w.OriginalProductKey = k[0].OA3xOriginalProductKey
-	} else {
-		w.OriginalProductKey = "N/A"
+	if fatal != nil {
+		return fatal
+	}
+	if reportedFailed == reported {
+		return errors.Join(errs...)
 	}
-
 	return nil
 }
 
-//******************************************************************************
-// Registry Reader
-//******************************************************************************
-
-type RegistryReader struct {
-	Key registry.Key
-}
+// collectProductKey
+// Only Windows has one, elsewhere it's reported as N/A.
+func (w *Windows) collectProductKey(ctx context.Context, src Sources) error {
+	var k []struct {
+		OA3xOriginalProductKey string
+	}
 
-func NewRegistryReader(path string) (*RegistryReader, error) {
-	key, err := registry.OpenKey(
-		registry.LOCAL_MACHINE,
-		path,
-		registry.QUERY_VALUE)
-	if err != nil {
-		return nil, err
+	if src.WMI == nil {
+		w.OriginalProductKey = "N/A"
+		return nil
 	}
 
-	return &RegistryReader{Key: key}, nil
-}
+	if err := query(ctx, src.WMI,
+		"SELECT OA3xOriginalProductKey FROM SoftwareLicensingService",
+		&k); err != nil {
+		return err
+	}
 
-func (r *RegistryReader) GetStringValue(name string) (string, error) {
-	val, _, err := r.Key.GetStringValue(name)
-	if err != nil {
-		return "", err
+	if len(k) > 0 && k[0].OA3xOriginalProductKey != "" {
+		w.OriginalProductKey = k[0].OA3xOriginalProductKey
+	} else {
+		w.OriginalProductKey = "N/A"
 	}
-	return val, nil
+
+	return nil
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 // CPU
 ////////////////////////////////////////////////////////////////////////////////
 
-func (c *CPUs) collect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), wmiTimeout)
-	defer cancel()
-
-	done := make(chan error, 1)
-	go func() {
-		done <- wmi.Query(
-			"SELECT Name, SocketDesignation, NumberOfCores, ThreadCount, "+
-				"L2CacheSize, L3CacheSize, MaxClockSpeed "+
-				"FROM Win32_Processor",
-			c)
-	}()
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-done:
-		if err != nil {
+var _ = RegisterSection(Section{
+	Name: "CPUs", Key: "cpu", Aliases: []string{"cpus"}, Order: 60,
+	Deps: []string{"smbios"},
+	New:  func() any { return &CPUs{} },
+	Collect: func(ctx context.Context, src Sources, s *Specs, node any) error {
+		src.smbios = s.smbios()
+		return node.(*CPUs).collect(ctx, src)
+	},
+})
+
+func (c *CPUs) collect(ctx context.Context, src Sources) error {
+	if src.SysFS != nil {
+		if err := c.collectSysFS(src.SysFS); err != nil {
 			return err
 		}
+		c.collectSMBIOS(src.smbios)
+		return nil
+	}
+
+	if err := query(ctx, src.WMI,
+		"SELECT Name, SocketDesignation, NumberOfCores, ThreadCount, "+
+			"L2CacheSize, L3CacheSize, MaxClockSpeed "+
+			"FROM Win32_Processor",
+		c); err != nil {
+		return err
 	}
 
 	return nil
@@ -292,25 +376,24 @@ func (c *CPUs) collect() error {
 // GPU
 ////////////////////////////////////////////////////////////////////////////////
 
-func (g *GPUs) collect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), wmiTimeout)
-	defer cancel()
-
-	done := make(chan error, 1)
-	go func() {
-		done <- wmi.Query(
-			"SELECT Name, AdapterCompatibility, AdapterDACType "+
-				"FROM Win32_VideoController",
-			g)
-	}()
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-done:
-		if err != nil {
-			return err
-		}
+var _ = RegisterSection(Section{
+	Name: "GPUs", Key: "gpu", Aliases: []string{"gpus"}, Order: 70,
+	New: func() any { return &GPUs{} },
+	Collect: func(ctx context.Context, src Sources, _ *Specs, node any) error {
+		return node.(*GPUs).collect(ctx, src)
+	},
+})
+
+func (g *GPUs) collect(ctx context.Context, src Sources) error {
+	if src.SysFS != nil {
+		return g.collectSysFS(src.SysFS)
+	}
+
+	if err := query(ctx, src.WMI,
+		"SELECT Name, AdapterCompatibility, AdapterDACType "+
+			"FROM Win32_VideoController",
+		g); err != nil {
+		return err
 	}
 
 	// Handle empty string
@@ -330,32 +413,39 @@ func (g *GPUs) collect() error {
 // Memory
 ////////////////////////////////////////////////////////////////////////////////
 
-func (m *Memory) collect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), wmiTimeout)
-	defer cancel()
-
-	done := make(chan error, 1)
-	go func() {
-		done <- wmi.Query(
-			"SELECT DeviceLocator, BankLabel, SMBIOSMemoryType, Speed, Capacity, "+
-				//"TypeDetail, Manufacturer, PartNumber, SerialNumber " + // not needed for now
-				"Manufacturer, PartNumber, SerialNumber "+
-				"FROM Win32_PhysicalMemory",
-			&m.DIMMs)
-	}()
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-done:
-		if err != nil {
-			return err
-		}
+var _ = RegisterSection(Section{
+	Name: "Memory", Key: "memory", Aliases: []string{"mem", "ram"}, Order: 80,
+	Deps: []string{"smbios"},
+	New:  func() any { return &Memory{} },
+	Collect: func(ctx context.Context, src Sources, s *Specs, node any) error {
+		src.smbios = s.smbios()
+		return node.(*Memory).collect(ctx, src)
+	},
+})
+
+func (m *Memory) collect(ctx context.Context, src Sources) error {
+	if src.smbios != nil && m.collectSMBIOS(src.smbios) {
+		return nil
+	}
+
+	if src.SysFS != nil {
+		return m.collectSysFS(src.SysFS)
+	}
+
+	if err := query(ctx, src.WMI,
+		"SELECT DeviceLocator, BankLabel, SMBIOSMemoryType, Speed, Capacity, "+
+			//"TypeDetail, Manufacturer, PartNumber, SerialNumber " + // not needed for now
+			"Manufacturer, PartNumber, SerialNumber "+
+			"FROM Win32_PhysicalMemory",
+		&m.DIMMs); err != nil {
+		return err
 	}
 
+	// Without SMBIOS, empty slots are unknown.
 	for i := range m.DIMMs {
 		m.TotalSize += m.DIMMs[i].Capacity
 		m.TotalSlot++
+		m.UsedSlot++
 
 		m.DIMMs[i].PartNumber = strings.TrimSpace(m.DIMMs[i].PartNumber)
 	}
@@ -380,24 +470,44 @@ func (m *Memory) collect() error {
 // Disks
 ////////////////////////////////////////////////////////////////////////////////
 
-func (d *Disks) collect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), wmiTimeout)
-	defer cancel()
-
-	done := make(chan error, 1)
-	go func() {
-		done <- wmi.Query(
-			"SELECT Model, Size, SerialNumber, Status FROM Win32_DiskDrive",
-			d)
-	}()
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-done:
-		if err != nil {
-			return err
-		}
+var _ = RegisterSection(Section{
+	Name: "Disks", Key: "disks", Aliases: []string{"disk"}, Order: 90,
+	New: func() any { return &Disks{} },
+	Collect: func(ctx context.Context, src Sources, _ *Specs, node any) error {
+		return node.(*Disks).collect(ctx, src)
+	},
+})
+
+func (d *Disks) collect(ctx context.Context, src Sources) error {
+	if src.SysFS != nil {
+		return d.collectSysFS(src.SysFS)
+	}
+
+	// DeviceID and Index are only needed to find the volumes and health.
+	var v []struct {
+		DeviceID     string
+		Index        uint64
+		Model        string
+		Size         DiskSize
+		SerialNumber string
+		Status       string
+	}
+
+	if err := query(ctx, src.WMI,
+		"SELECT DeviceID, Index, Model, Size, SerialNumber, Status FROM Win32_DiskDrive",
+		&v); err != nil {
+		return err
+	}
+
+	ids, indexes := make([]string, len(v)), make([]uint64, len(v))
+	for i, w := range v {
+		ids[i], indexes[i] = w.DeviceID, w.Index
+		*d = append(*d, Disk{
+			Model:        w.Model,
+			Size:         w.Size,
+			SerialNumber: w.SerialNumber,
+			Status:       w.Status,
+		})
 	}
 
 	// Handle empty string
@@ -410,34 +520,41 @@ func (d *Disks) collect() error {
 		}
 	}
 
-	return nil
+	return errors.Join(d.collectHealth(ctx, src.WMI, indexes), d.collectVolumes(ctx, src.WMI, ids))
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 // Network Adapters
 ////////////////////////////////////////////////////////////////////////////////
 
-func (n *NetAdapters) collect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), wmiTimeout)
-	defer cancel()
-
-	done := make(chan error, 1)
-	go func() {
-		done <- wmi.Query(
-			"SELECT Name, MACAddress, Manufacturer FROM Win32_NetworkAdapter "+
-				"WHERE Manufacturer <> 'Microsoft'",
-			n)
-	}()
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-done:
-		if err != nil {
-			return err
-		}
+var _ = RegisterSection(Section{
+	Name: "NetAdapters", Key: "network", Aliases: []string{"net", "netadapters"}, Order: 100,
+	New: func() any { return &NetAdapters{} },
+	Collect: func(ctx context.Context, src Sources, _ *Specs, node any) error {
+		return node.(*NetAdapters).collect(ctx, src)
+	},
+})
+
+func (n *NetAdapters) collect(ctx context.Context, src Sources) error {
+	if src.SysFS != nil {
+		return n.collectSysFS(src.SysFS)
 	}
 
+	if err := query(ctx, src.WMI,
+		"SELECT Name, MACAddress, Manufacturer FROM Win32_NetworkAdapter "+
+			"WHERE Manufacturer <> 'Microsoft'",
+		n); err != nil {
+		return err
+	}
+
+	n.dropVirtual()
+
+	return nil
+}
+
+// dropVirtual
+// Filter out VPN, hypervisor and other software adapters by manufacturer.
+func (n *NetAdapters) dropVirtual() {
 	realAdapters := (*n)[:0] // same capacity as *n, no reallocation
 	virtAdapters := []string{
 		"windows",
@@ -459,14 +576,20 @@ outer:
 		realAdapters = append(realAdapters, v)
 	}
 	*n = realAdapters
-
-	return nil
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 // Current User
 ////////////////////////////////////////////////////////////////////////////////
 
+var _ = RegisterSection(Section{
+	Name: "CurrentUser", Key: "user", Aliases: []string{"currentuser"}, Order: 10,
+	New: func() any { return &CurrentUser{} },
+	Collect: func(_ context.Context, _ Sources, _ *Specs, node any) error {
+		return node.(*CurrentUser).collect()
+	},
+})
+
 func (u *CurrentUser) collect() error {
 	v, err := user.Current()
 	if err != nil {
@@ -482,7 +605,52 @@ func (u *CurrentUser) collect() error {
 // Windows info
 ////////////////////////////////////////////////////////////////////////////////
 
-func (w *Windows) collect() error {
+var _ = RegisterSection(Section{
+	Name: "Windows", Key: "windows", Aliases: []string{"os"}, Order: 20,
+	New: func() any { return &Windows{} },
+	Collect: func(ctx context.Context, src Sources, _ *Specs, node any) error {
+		return node.(*Windows).collect(ctx, src)
+	},
+})
+
+// Part of Windows, only when asked for, since the query is slow.
+var _ = RegisterSection(Section{
+	Name: "ProductKey", Key: "key", Order: 25,
+	Deps:     []string{"windows"},
+	Optional: true,
+	Collect: func(ctx context.Context, src Sources, s *Specs, _ any) error {
+		w := s.node("windows").(*Windows)
+		if err := w.collectProductKey(ctx, src); err != nil {
+			w.OriginalProductKey = "N/A"
+			return fmt.Errorf("product key: %w", err)
+		}
+		return nil
+	},
+})
+
+// hiddenProductKey
+// OriginalProductKey unless the key section is collected.
+const hiddenProductKey = "***********"
+
+// HasProductKey
+// Whether the report holds the product key, see the key section.
+func (s *Specs) HasProductKey() bool {
+	w, ok := s.node("windows").(*Windows)
+	if !ok {
+		return false
+	}
+	switch w.OriginalProductKey {
+	case "", "N/A", hiddenProductKey:
+		return false
+	}
+	return true
+}
+
+func (w *Windows) collect(ctx context.Context, src Sources) error {
+	if src.SysFS != nil {
+		return w.collectSysFS(src.SysFS)
+	}
+
 	// wmi.Query receiver struct must have exact fields as the queried ones,
 	// so here's a temporary struct to hold the results.
 	var v []struct {
@@ -494,25 +662,16 @@ func (w *Windows) collect() error {
 		RegisteredUser string
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), wmiTimeout)
-	defer cancel()
-
-	done := make(chan error, 1)
-	go func() {
-		done <- wmi.Query(
-			"SELECT Caption, BuildNumber, SerialNumber, CSName, InstallDate,"+
-				"RegisteredUser "+
-				"FROM Win32_OperatingSystem",
-			&v)
-	}()
+	if err := query(ctx, src.WMI,
+		"SELECT Caption, BuildNumber, SerialNumber, CSName, InstallDate,"+
+			"RegisteredUser "+
+			"FROM Win32_OperatingSystem",
+		&v); err != nil {
+		return err
+	}
 
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-done:
-		if err != nil {
-			return err
-		}
+	if len(v) == 0 {
+		return errors.New("Win32_OperatingSystem returned nothing")
 	}
 
 	*w = Windows{
@@ -522,132 +681,9 @@ func (w *Windows) collect() error {
 		SerialNumber:       v[0].SerialNumber,
 		InstallDate:        v[0].InstallDate,
 		RegisteredUser:     v[0].RegisteredUser,
-		OriginalProductKey: "***********", // default when is not requested
+		OriginalProductKey: hiddenProductKey,
 	}
 
 	// Collect Windows feature update version, e.g., 24H2
-	reg, err := NewRegistryReader(`SOFTWARE\Microsoft\Windows NT\CurrentVersion`)
-	if err != nil {
-		return err
-	}
-	defer func(Key registry.Key) {
-		err := Key.Close()
-		if err != nil {
-			return
-		}
-	}(reg.Key)
-
-	w.Version, err = reg.GetStringValue("DisplayVersion")
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-////////////////////////////////////////////////////////////////////////////////
-// BIOS, Baseboard, System (BBS)
-////////////////////////////////////////////////////////////////////////////////
-
-func (b *BBS) collect() error {
-	reg, err := NewRegistryReader(`HARDWARE\Description\System\BIOS`)
-
-	if err != nil {
-		return err
-	}
-	defer func(Key registry.Key) {
-		err := Key.Close()
-		if err != nil {
-			return
-		}
-	}(reg.Key)
-
-	b.BIOS.Vendor, err = reg.GetStringValue("BIOSVendor")
-	if err != nil {
-		return err
-	}
-	if b.BIOS.Vendor == "" {
-		b.BIOS.Vendor = "N/A"
-	}
-
-	b.BIOS.Version, err = reg.GetStringValue("BIOSVersion")
-	if err != nil {
-		return err
-	}
-	if b.BIOS.Version == "" {
-		b.BIOS.Version = "N/A"
-	}
-
-	b.BIOS.ReleaseDate, err = reg.GetStringValue("BIOSReleaseDate")
-	if err != nil {
-		return err
-	}
-	if b.BIOS.ReleaseDate == "" {
-		b.BIOS.ReleaseDate = "N/A"
-	}
-
-	b.Baseboard.Manufacturer, err = reg.GetStringValue("BaseBoardManufacturer")
-	if err != nil {
-		return err
-	}
-	if b.Baseboard.Manufacturer == "" {
-		b.Baseboard.Manufacturer = "N/A"
-	}
-
-	b.Baseboard.Product, err = reg.GetStringValue("BaseBoardProduct")
-	if err != nil {
-		return err
-	}
-	if b.Baseboard.Product == "" {
-		b.Baseboard.Product = "N/A"
-	}
-
-	b.Baseboard.Version, err = reg.GetStringValue("BaseBoardVersion")
-	if err != nil {
-		return err
-	}
-	if b.Baseboard.Version == "" {
-		b.Baseboard.Version = "N/A"
-	}
-
-	b.System.Manufacturer, err = reg.GetStringValue("SystemManufacturer")
-	if err != nil {
-		return err
-	}
-	if b.System.Manufacturer == "" {
-		b.System.Manufacturer = "N/A"
-	}
-
-	b.System.Family, err = reg.GetStringValue("SystemFamily")
-	if err != nil {
-		return err
-	}
-	if b.System.Family == "" {
-		b.System.Family = "N/A"
-	}
-
-	b.System.Version, err = reg.GetStringValue("SystemVersion")
-	if err != nil {
-		return err
-	}
-	if b.System.Version == "" {
-		b.System.Version = "N/A"
-	}
-
-	b.System.ProductName, err = reg.GetStringValue("SystemProductName")
-	if err != nil {
-		return err
-	}
-	if b.System.ProductName == "" {
-		b.System.ProductName = "N/A"
-	}
-
-	b.System.SKU, err = reg.GetStringValue("SystemSKU")
-	if err != nil {
-		return err
-	}
-	if b.System.SKU == "" {
-		b.System.SKU = "N/A"
-	}
-
-	return nil
+	return w.collectVersion(src.Registry)
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"slices"
+)
+
+// Sections filled from the SMBIOS table, see Sources.
+// Empty strings are reported as N/A, as everywhere else.
+
+////////////////////////////////////////////////////////////////////////////////
+// CPU
+////////////////////////////////////////////////////////////////////////////////
+
+// collectSMBIOS
+// Only the socket designation is missing from /proc/cpuinfo,
+// and the cache sizes when /sys has no cache directories, e.g., in some VMs.
+func (c *CPUs) collectSMBIOS(t *SMBIOS) {
+	if t == nil {
+		return
+	}
+
+	var procs []SMBIOSProcessor
+	for _, p := range t.Processors {
+		if p.Populated {
+			procs = append(procs, p)
+		}
+	}
+
+	for i := range min(len(*c), len(procs)) {
+		cpu, p := &(*c)[i], procs[i]
+
+		if p.SocketDesignation != "" {
+			cpu.SocketDesignation = p.SocketDesignation
+		}
+
+		// In KiB, as WMI and cacheSizes have them.
+		if l2, ok := t.Cache(p.L2Handle); ok && cpu.L2CacheSize == 0 {
+			cpu.L2CacheSize = L2CacheSize(l2.InstalledSize >> 10)
+		}
+		if l3, ok := t.Cache(p.L3Handle); ok && cpu.L3CacheSize == 0 {
+			cpu.L3CacheSize = L3CacheSize(l3.InstalledSize >> 10)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Memory
+////////////////////////////////////////////////////////////////////////////////
+
+// collectSMBIOS
+// Every slot has a memory device structure, empty ones have no size.
+// False if the table has no memory devices at all.
+func (m *Memory) collectSMBIOS(t *SMBIOS) bool {
+	// Only system memory arrays, not cache or video memory.
+	var arrays []uint16
+	for _, a := range t.MemoryArrays {
+		if a.Use == smbiosSystemMemory {
+			arrays = append(arrays, a.Handle)
+			m.MaxCapacity += DIMMCapacity(a.MaxCapacity)
+		}
+	}
+
+	for _, d := range t.MemoryDevices {
+		if len(arrays) > 0 && !slices.Contains(arrays, d.ArrayHandle) {
+			continue
+		}
+		m.TotalSlot++
+
+		if d.Size == 0 {
+			continue
+		}
+
+		dimm := DIMM{
+			DeviceLocator:    d.DeviceLocator,
+			BankLabel:        d.BankLocator,
+			SMBIOSMemoryType: DIMMType(d.MemoryType),
+			Speed:            DIMMSpeed(d.Speed),
+			Capacity:         DIMMCapacity(d.Size),
+			Manufacturer:     d.Manufacturer,
+			PartNumber:       d.PartNumber,
+			SerialNumber:     d.SerialNumber,
+		}
+		for _, v := range []*string{
+			&dimm.DeviceLocator, &dimm.BankLabel,
+			&dimm.Manufacturer, &dimm.PartNumber, &dimm.SerialNumber,
+		} {
+			if *v == "" {
+				*v = "N/A"
+			}
+		}
+
+		m.DIMMs = append(m.DIMMs, dimm)
+		m.TotalSize += dimm.Capacity
+		m.UsedSlot++
+	}
+
+	return m.TotalSlot > 0
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// BIOS, Baseboard, System (BBS)
+////////////////////////////////////////////////////////////////////////////////
+
+func (b *BBS) collectSMBIOS(t *SMBIOS) {
+	var bios SMBIOSBIOS
+	var system SMBIOSSystem
+	var board SMBIOSBaseboard
+	var chassis SMBIOSChassis
+
+	if len(t.BIOS) > 0 {
+		bios = t.BIOS[0]
+	}
+	if len(t.Systems) > 0 {
+		system = t.Systems[0]
+	}
+	if len(t.Baseboards) > 0 {
+		board = t.Baseboards[0]
+	}
+	if len(t.Chassis) > 0 {
+		chassis = t.Chassis[0]
+	}
+
+	b.BIOS = BIOS{
+		Vendor:      bios.Vendor,
+		Version:     bios.Version,
+		ReleaseDate: bios.ReleaseDate,
+	}
+	b.Baseboard = Baseboard{
+		Manufacturer: board.Manufacturer,
+		Product:      board.Product,
+		Version:      board.Version,
+	}
+	b.System = System{
+		Manufacturer: system.Manufacturer,
+		Family:       system.Family,
+		Version:      system.Version,
+		ProductName:  system.ProductName,
+		SKU:          system.SKU,
+		UUID:         system.UUID,
+		ChassisType:  chassis.Type,
+	}
+
+	for _, v := range []*string{
+		&b.BIOS.Vendor, &b.BIOS.Version, &b.BIOS.ReleaseDate,
+		&b.Baseboard.Manufacturer, &b.Baseboard.Product, &b.Baseboard.Version,
+		&b.System.Manufacturer, &b.System.Family, &b.System.Version,
+		&b.System.ProductName, &b.System.SKU, &b.System.UUID,
+	} {
+		if *v == "" {
+			*v = "N/A"
+		}
+	}
+}
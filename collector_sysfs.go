@@ -0,0 +1,554 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+)
+
+// The Linux backend.
+// Every section is filled from a root filesystem,
+// i.e., os.DirFS("/") or a recorded fixture, see Sources.
+// Paths are relative to that root, hence no leading slash.
+// Values with no Linux counterpart are reported as N/A.
+
+////////////////////////////////////////////////////////////////////////////////
+// CPU
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *CPUs) collectSysFS(fsys fs.FS) error {
+	procs, err := readCPUInfo(fsys)
+	if err != nil {
+		return err
+	}
+
+	// One CPU per physical package, like Win32_Processor.
+	var ids []string
+	pkgs := map[string][]map[string]string{}
+	for _, p := range procs {
+		id := p["physical id"]
+		if _, ok := pkgs[id]; !ok {
+			ids = append(ids, id)
+		}
+		pkgs[id] = append(pkgs[id], p)
+	}
+
+	for _, id := range ids {
+		pkg := pkgs[id]
+
+		cpu := CPU{
+			Name:              pkg[0]["model name"],
+			SocketDesignation: "N/A",
+			ThreadCount:       uint64(len(pkg)),
+		}
+		if cpu.Name == "" {
+			cpu.Name = "N/A"
+		}
+
+		cores := map[string]bool{}
+		for _, p := range pkg {
+			cores[p["core id"]] = true
+		}
+		cpu.NumberOfCores = uint64(len(cores))
+		if n, err := strconv.ParseUint(pkg[0]["cpu cores"], 10, 64); err == nil {
+			cpu.NumberOfCores = n
+		}
+
+		// cpufreq is in kHz, WMI reports MHz.
+		base := "sys/devices/system/cpu/cpu" + pkg[0]["processor"]
+		if khz, err := strconv.ParseUint(readSysFS(fsys, base+"/cpufreq/cpuinfo_max_freq"), 10, 64); err == nil {
+			cpu.MaxClockSpeed = CPUMaxClockSpeed(khz / 1e3)
+		} else if mhz, err := strconv.ParseFloat(pkg[0]["cpu MHz"], 64); err == nil {
+			cpu.MaxClockSpeed = CPUMaxClockSpeed(mhz)
+		}
+
+		l2, l3 := cacheSizes(fsys, pkg)
+		cpu.L2CacheSize, cpu.L3CacheSize = L2CacheSize(l2), L3CacheSize(l3)
+
+		*c = append(*c, cpu)
+	}
+
+	return nil
+}
+
+// readCPUInfo
+// Parse /proc/cpuinfo into one map per logical processor.
+func readCPUInfo(fsys fs.FS) ([]map[string]string, error) {
+	data, err := fs.ReadFile(fsys, "proc/cpuinfo")
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []map[string]string
+	var p map[string]string
+
+	sc := bufio.NewScanner(strings.NewReader(string(data)))
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			p = nil
+			continue
+		}
+		if p == nil {
+			p = map[string]string{}
+			procs = append(procs, p)
+		}
+		p[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	return procs, sc.Err()
+}
+
+// cacheSizes
+// Sum the L2 and L3 caches of a package in KiB, as WMI does.
+// Each cache instance is counted once, however many CPUs share it.
+func cacheSizes(fsys fs.FS, pkg []map[string]string) (l2, l3 uint64) {
+	seen := map[string]bool{}
+
+	for _, p := range pkg {
+		dir := "sys/devices/system/cpu/cpu" + p["processor"] + "/cache"
+		entries, err := fs.ReadDir(fsys, dir)
+		if err != nil {
+			continue
+		}
+
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Name(), "index") {
+				continue
+			}
+			idx := dir + "/" + e.Name()
+
+			level := readSysFS(fsys, idx+"/level")
+			key := level + "/" + readSysFS(fsys, idx+"/shared_cpu_list")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			size, err := units.RAMInBytes(readSysFS(fsys, idx+"/size"))
+			if err != nil {
+				continue
+			}
+
+			switch level {
+			case "2":
+				l2 += uint64(size) / units.KiB
+			case "3":
+				l3 += uint64(size) / units.KiB
+			}
+		}
+	}
+
+	return l2, l3
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GPU
+////////////////////////////////////////////////////////////////////////////////
+
+var drmCard = regexp.MustCompile(`^card\d+$`)
+
+func (g *GPUs) collectSysFS(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, "sys/class/drm")
+	if err != nil {
+		return nil // no DRM, no GPU
+	}
+
+	for _, e := range entries {
+		if !drmCard.MatchString(e.Name()) {
+			continue
+		}
+		dev := "sys/class/drm/" + e.Name() + "/device"
+
+		vendor, device := readSysFS(fsys, dev+"/vendor"), readSysFS(fsys, dev+"/device")
+		vendorName, deviceName := pciName(fsys, vendor, device)
+
+		gpu := GPU{
+			Name:                 deviceName,
+			AdapterCompatibility: vendorName,
+			AdapterDACType:       "N/A",
+		}
+		if gpu.Name == "" {
+			gpu.Name = readUEvent(fsys, dev)["PCI_ID"]
+		}
+		if gpu.Name == "" {
+			gpu.Name = "N/A"
+		}
+		if gpu.AdapterCompatibility == "" {
+			gpu.AdapterCompatibility = "N/A"
+		}
+
+		*g = append(*g, gpu)
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Memory
+////////////////////////////////////////////////////////////////////////////////
+
+func (m *Memory) collectSysFS(fsys fs.FS) error {
+	data, err := fs.ReadFile(fsys, "proc/meminfo")
+	if err != nil {
+		return err
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(string(data)))
+	for sc.Scan() {
+		f := strings.Fields(sc.Text())
+		if len(f) < 2 || f[0] != "MemTotal:" {
+			continue
+		}
+
+		kb, err := strconv.ParseUint(f[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		m.TotalSize = DIMMCapacity(kb * units.KiB)
+	}
+
+	return sc.Err()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Disks
+////////////////////////////////////////////////////////////////////////////////
+
+func (d *Disks) collectSysFS(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, "sys/block")
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		dir := "sys/block/" + e.Name()
+
+		// Loop, RAM, device mapper and the like have no backing device.
+		// Optical drives aren't in Win32_DiskDrive either.
+		if !existsSysFS(fsys, dir+"/device") || strings.HasPrefix(e.Name(), "sr") {
+			continue
+		}
+
+		sectors, _ := strconv.ParseUint(readSysFS(fsys, dir+"/size"), 10, 64)
+		udev := udevProps(fsys, dir)
+
+		disk := Disk{
+			Model:           readSysFS(fsys, dir+"/device/model"),
+			Size:            DiskSize(sectors * 512), // always 512-byte sectors
+			SerialNumber:    diskSerial(fsys, dir),
+			Status:          "OK",
+			MediaType:       mediaTypesSysFS[readSysFS(fsys, dir+"/queue/rotational")],
+			BusType:         diskBus(e.Name(), udev),
+			FirmwareVersion: cmp.Or(readSysFS(fsys, dir+"/device/firmware_rev"), readSysFS(fsys, dir+"/device/rev")),
+			PartitionStyle:  partitionStyles[udev["ID_PART_TABLE_TYPE"]],
+		}
+		if disk.Model == "" {
+			disk.Model = "N/A"
+		}
+		if disk.SerialNumber == "" {
+			disk.SerialNumber = "N/A"
+		}
+		if disk.PartitionStyle == "" {
+			disk.PartitionStyle = "N/A"
+		}
+		// SMART needs root and ioctls, not sysfs.
+		disk.setHealthNA()
+		if state := readSysFS(fsys, dir+"/device/state"); state != "" &&
+			state != "running" && state != "live" {
+			disk.Status = state
+		}
+
+		*d = append(*d, disk)
+	}
+
+	return nil
+}
+
+// partitionStyles
+// As udev's blkid tells, the same as on Windows.
+var partitionStyles = map[string]string{"gpt": "GPT", "dos": "MBR"}
+
+// mediaTypesSysFS
+// By whether the disk spins, as the kernel tells.
+var mediaTypesSysFS = map[string]string{"0": "SSD", "1": "HDD"}
+
+// busTypesSysFS
+// udev's ID_BUS, named as MSFT_PhysicalDisk.BusType.
+var busTypesSysFS = map[string]string{"ata": "SATA", "scsi": "SCSI", "usb": "USB"}
+
+// diskBus
+// NVMe disks have no ID_BUS, but their name tells.
+func diskBus(name string, udev map[string]string) string {
+	if strings.HasPrefix(name, "nvme") {
+		return "NVMe"
+	}
+	return busTypesSysFS[udev["ID_BUS"]]
+}
+
+// diskSerial
+// NVMe exposes the serial directly, SCSI/SATA through VPD page 0x80,
+// udev has it for the rest.
+func diskSerial(fsys fs.FS, dir string) string {
+	if s := readSysFS(fsys, dir+"/device/serial"); s != "" {
+		return s
+	}
+
+	if b, err := fs.ReadFile(fsys, dir+"/device/vpd_pg80"); err == nil && len(b) > 4 {
+		return strings.TrimSpace(string(b[4:]))
+	}
+
+	return udevProps(fsys, dir)["ID_SERIAL_SHORT"]
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Network Adapters
+////////////////////////////////////////////////////////////////////////////////
+
+func (n *NetAdapters) collectSysFS(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, "sys/class/net")
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		dir := "sys/class/net/" + e.Name()
+
+		// Loopback, bridges, tunnels and the like have no backing device.
+		if !existsSysFS(fsys, dir+"/device") {
+			continue
+		}
+
+		vendor, device := readSysFS(fsys, dir+"/device/vendor"), readSysFS(fsys, dir+"/device/device")
+		vendorName, deviceName := pciName(fsys, vendor, device)
+
+		adapter := NetAdapter{
+			Name:         deviceName,
+			MACAddress:   strings.ToUpper(readSysFS(fsys, dir+"/address")),
+			Manufacturer: vendorName,
+		}
+		if adapter.Name == "" {
+			adapter.Name = e.Name()
+		}
+		if adapter.Manufacturer == "" {
+			adapter.Manufacturer = readUEvent(fsys, dir+"/device")["DRIVER"]
+		}
+		if adapter.Manufacturer == "" {
+			adapter.Manufacturer = "N/A"
+		}
+
+		*n = append(*n, adapter)
+	}
+
+	n.dropVirtual()
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Operating System
+////////////////////////////////////////////////////////////////////////////////
+
+func (w *Windows) collectSysFS(fsys fs.FS) error {
+	osRelease := readOSRelease(fsys)
+
+	*w = Windows{
+		CSName:             readSysFS(fsys, "proc/sys/kernel/hostname"),
+		Caption:            osRelease["PRETTY_NAME"],
+		Version:            osRelease["VERSION_ID"],
+		BuildNumber:        readSysFS(fsys, "proc/sys/kernel/osrelease"),
+		SerialNumber:       readSysFS(fsys, "etc/machine-id"),
+		RegisteredUser:     "N/A",
+		OriginalProductKey: hiddenProductKey,
+	}
+
+	for _, v := range []*string{&w.CSName, &w.Caption, &w.Version, &w.BuildNumber, &w.SerialNumber} {
+		if *v == "" {
+			*v = "N/A"
+		}
+	}
+
+	return nil
+}
+
+func readOSRelease(fsys fs.FS) map[string]string {
+	m := map[string]string{}
+
+	data, err := fs.ReadFile(fsys, "etc/os-release")
+	if err != nil {
+		if data, err = fs.ReadFile(fsys, "usr/lib/os-release"); err != nil {
+			return m
+		}
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(k, "#") {
+			continue
+		}
+		if u, err := strconv.Unquote(v); err == nil {
+			v = u
+		}
+		m[k] = strings.Trim(v, `'`)
+	}
+
+	return m
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// BIOS, Baseboard, System (BBS)
+////////////////////////////////////////////////////////////////////////////////
+
+func (b *BBS) collectSysFS(fsys fs.FS) error {
+	fields := []struct {
+		dst  *string
+		file string
+	}{
+		{&b.BIOS.Vendor, "bios_vendor"},
+		{&b.BIOS.Version, "bios_version"},
+		{&b.BIOS.ReleaseDate, "bios_date"},
+		{&b.Baseboard.Manufacturer, "board_vendor"},
+		{&b.Baseboard.Product, "board_name"},
+		{&b.Baseboard.Version, "board_version"},
+		{&b.System.Manufacturer, "sys_vendor"},
+		{&b.System.Family, "product_family"},
+		{&b.System.Version, "product_version"},
+		{&b.System.ProductName, "product_name"},
+		{&b.System.SKU, "product_sku"},
+		{&b.System.UUID, "product_uuid"}, // root only
+	}
+
+	for _, f := range fields {
+		*f.dst = readSysFS(fsys, "sys/class/dmi/id/"+f.file)
+		if *f.dst == "" {
+			*f.dst = "N/A"
+		}
+	}
+
+	b.System.UUID = strings.ToUpper(b.System.UUID)
+
+	chassis, _ := strconv.ParseUint(readSysFS(fsys, "sys/class/dmi/id/chassis_type"), 10, 64)
+	b.System.ChassisType = ChassisType(chassis)
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////////////
+
+// readSysFS
+// Read a single-value file, empty if unreadable.
+func readSysFS(fsys fs.FS, name string) string {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func existsSysFS(fsys fs.FS, name string) bool {
+	_, err := fs.Stat(fsys, name)
+	return err == nil
+}
+
+// readUEvent
+// Parse the KEY=value pairs of a device's uevent file.
+func readUEvent(fsys fs.FS, dev string) map[string]string {
+	m := map[string]string{}
+	for _, line := range strings.Split(readSysFS(fsys, dev+"/uevent"), "\n") {
+		if k, v, ok := strings.Cut(line, "="); ok {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// udevProps
+// The E: properties udev keeps for a block device, e.g., ID_SERIAL_SHORT.
+func udevProps(fsys fs.FS, dir string) map[string]string {
+	m := map[string]string{}
+
+	dev := readSysFS(fsys, dir+"/dev") // major:minor
+	if dev == "" {
+		return m
+	}
+
+	for _, line := range strings.Split(readSysFS(fsys, "run/udev/data/b"+dev), "\n") {
+		if k, v, ok := strings.Cut(strings.TrimPrefix(line, "E:"), "="); ok && strings.HasPrefix(line, "E:") {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// pciName
+// Look up vendor and device names in the pci.ids database, if installed.
+// IDs are as in sysfs, e.g., 0x8086.
+func pciName(fsys fs.FS, vendor, device string) (vendorName, deviceName string) {
+	vendor = strings.TrimPrefix(strings.ToLower(vendor), "0x")
+	device = strings.TrimPrefix(strings.ToLower(device), "0x")
+	if vendor == "" {
+		return "", ""
+	}
+
+	for _, db := range []string{"usr/share/hwdata/pci.ids", "usr/share/misc/pci.ids"} {
+		data, err := fs.ReadFile(fsys, db)
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			switch {
+			case strings.HasPrefix(line, "#"), line == "":
+				continue
+
+			// Vendor lines are unindented, devices are one tab in.
+			case !strings.HasPrefix(line, "\t"):
+				if vendorName != "" {
+					return vendorName, deviceName
+				}
+				if id, name, ok := strings.Cut(line, "  "); ok && id == vendor {
+					vendorName = name
+				}
+
+			case vendorName != "" && !strings.HasPrefix(line, "\t\t"):
+				if id, name, ok := strings.Cut(line[1:], "  "); ok && id == device {
+					return vendorName, name
+				}
+			}
+		}
+
+		if vendorName != "" {
+			return vendorName, deviceName
+		}
+	}
+
+	if name, ok := pciVendors[vendor]; ok {
+		return name, ""
+	}
+	return fmt.Sprintf("0x%s", vendor), ""
+}
+
+// pciVendors
+// Fallback for the usual suspects when pci.ids isn't installed.
+var pciVendors = map[string]string{
+	"8086": "Intel Corporation",
+	"1022": "Advanced Micro Devices, Inc. [AMD]",
+	"1002": "Advanced Micro Devices, Inc. [AMD/ATI]",
+	"10de": "NVIDIA Corporation",
+	"10ec": "Realtek Semiconductor Co., Ltd.",
+	"14e4": "Broadcom Inc. and subsidiaries",
+	"168c": "Qualcomm Atheros",
+	"17cb": "Qualcomm Technologies, Inc",
+	"15b3": "Mellanox Technologies",
+	"1af4": "Red Hat, Inc.",
+	"15ad": "VMware",
+	"1414": "Microsoft Corporation",
+}
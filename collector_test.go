@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// Only a collected key counts, not the placeholder nor N/A,
+// since the report is then written owner-only, see WriteHTML.
+func TestHasProductKey(t *testing.T) {
+	src, err := ReplaySources("testdata/replay/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func(sections ...string) *Specs {
+		t.Helper()
+		var s Specs
+		if err := s.Collect(context.Background(), src, CollectOptions{Sections: sections}); err != nil {
+			t.Fatal(err)
+		}
+		return &s
+	}
+
+	if s := collect("windows"); s.HasProductKey() {
+		t.Errorf("not requested, %q is a key", s.node("windows").(*Windows).OriginalProductKey)
+	}
+	// The fixture has none, i.e., no OEM key in the firmware.
+	s := collect("windows", "key")
+	if s.HasProductKey() {
+		t.Errorf("none, %q is a key", s.node("windows").(*Windows).OriginalProductKey)
+	}
+	s.node("windows").(*Windows).OriginalProductKey = "XXXXX-XXXXX-XXXXX-XXXXX-XXXXX"
+	if !s.HasProductKey() {
+		t.Error("a key isn't")
+	}
+	if (&Specs{}).HasProductKey() {
+		t.Error("no Windows section has a key")
+	}
+}
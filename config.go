@@ -0,0 +1,116 @@
+//go:build windows && gui
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LauncherConfig
+// winspecter.toml next to the launcher, e.g.,
+//
+//	OutputDir = '%USERPROFILE%\Documents\Winspecter'
+//	FileName = '{{.Windows.CSName}}_{{.System.SKU}}_{{.Time}}.html'
+//	OpenBrowser = false
+//	FileMode = '0600'
+//	OwnerOnly = true
+//	KeyDir = '%USERPROFILE%\Documents\Winspecter\Keys'
+//
+// Every setting is optional, so is the file.
+type LauncherConfig struct {
+	// Where the report goes, created if missing, default current directory.
+	// Environment variables, e.g., %USERPROFILE%, are expanded.
+	OutputDir string
+
+	// See Specs.ExpandName, default DefaultHTMLPattern.
+	FileName string
+
+	// Open the report in the default browser once written, default true.
+	OpenBrowser bool
+
+	// Octal, e.g., '0644', default DefaultReportMode.
+	// On Windows, it only tells apart read-only files, e.g., '0400'.
+	FileMode string
+
+	// Only the current user gets to read the report, default true,
+	// otherwise it's whatever the directory allows.
+	OwnerOnly bool
+
+	// Where the report goes, instead of OutputDir, since it has the product key,
+	// owner only, whatever OwnerOnly says. Environment variables are expanded.
+	KeyDir string
+}
+
+const launcherConfigName = "winspecter.toml"
+
+// LoadLauncherConfig
+// Read the config next to the executable, defaults if there's none.
+func LoadLauncherConfig() (LauncherConfig, error) {
+	c := LauncherConfig{
+		FileName:    DefaultHTMLPattern,
+		OpenBrowser: true,
+		FileMode:    fmt.Sprintf("%04o", DefaultReportMode),
+		OwnerOnly:   true,
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return c, err
+	}
+
+	path := filepath.Join(filepath.Dir(exe), launcherConfigName)
+	if _, err := toml.DecodeFile(path, &c); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return c, err
+	}
+
+	c.OutputDir = expandWinEnv(c.OutputDir)
+	c.KeyDir = expandWinEnv(c.KeyDir)
+
+	if _, err := c.mode(); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (c LauncherConfig) mode() (os.FileMode, error) {
+	m, err := ParseFileMode(c.FileMode)
+	if err != nil {
+		return 0, fmt.Errorf("%s: FileMode: %w", launcherConfigName, err)
+	}
+	return m, nil
+}
+
+var winEnv = regexp.MustCompile(`%([^%]+)%`)
+
+// expandWinEnv
+// Expand %VAR%, as cmd.exe does, leaving unknown ones as is.
+func expandWinEnv(s string) string {
+	return winEnv.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := os.LookupEnv(m[1 : len(m)-1]); ok {
+			return v
+		}
+		return m
+	})
+}
+
+// HTMLOptions
+// Where the launcher writes the report.
+func (c LauncherConfig) HTMLOptions() HTMLOptions {
+	mode, _ := c.mode() // checked by LoadLauncherConfig
+
+	return HTMLOptions{
+		Dir:     c.OutputDir,
+		Pattern: c.FileName,
+		KeyDir:  c.KeyDir,
+		Mode:    mode,
+		Shared:  !c.OwnerOnly,
+	}
+}
@@ -0,0 +1,402 @@
+//go:build cli
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions
+// Zero value is plain RFC 4180: comma-separated, LF line endings, no BOM,
+// as many columns as the machine has components.
+type CSVOptions struct {
+	Comma    rune // column delimiter, 0 means comma
+	CRLF     bool // CRLF line endings, as RFC 4180 says, instead of LF
+	BOM      bool // UTF-8 BOM, for Excel to get the encoding right
+	NoHeader bool // values only, to append to an existing file
+
+	Layout CSVLayout
+	Limits CSVLimits // for CSVFixed
+}
+
+// CSVLayout
+// How repeated components, e.g., CPUs, DIMMs and disks, are laid out.
+type CSVLayout string
+
+const (
+	// As many columns as the machine has, e.g., DIMM0 ... DIMM3.
+	CSVWide CSVLayout = "wide"
+
+	// Exactly CSVLimits columns per group, empty if the machine has fewer,
+	// so rows from different machines line up.
+	CSVFixed CSVLayout = "fixed"
+
+	// One row per component, e.g., each CPU, DIMM and disk,
+	// keyed by device name, with a column per field of any component.
+	CSVLong CSVLayout = "long"
+)
+
+// CSVLimits
+// How many of each repeated group go in a fixed layout, by group name,
+// e.g., DIMMs, case-insensitive, Default for the others.
+type CSVLimits struct {
+	Default int
+	Groups  map[string]int
+}
+
+const DefaultCSVLimit = 4
+
+func ParseCSVLayout(s string) (CSVLayout, error) {
+	switch l := CSVLayout(strings.ToLower(s)); l {
+	case "", CSVWide, CSVFixed, CSVLong:
+		return l, nil
+	default:
+		return "", fmt.Errorf("unknown CSV layout %q, expecting wide, fixed or long", s)
+	}
+}
+
+// ParseCSVLimits
+// A comma-separated list of a default and per-group limits,
+// e.g., 4,DIMMs=8,Disks=6.
+func ParseCSVLimits(s string) (CSVLimits, error) {
+	l := CSVLimits{Default: DefaultCSVLimit, Groups: map[string]int{}}
+
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		group, num, ok := strings.Cut(v, "=")
+		if !ok {
+			group, num = "", v
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(num))
+		if err != nil || n < 0 {
+			return l, fmt.Errorf("invalid CSV limit %q", v)
+		}
+
+		if group == "" {
+			l.Default = n
+		} else {
+			l.Groups[strings.ToLower(strings.TrimSpace(group))] = n
+		}
+	}
+
+	return l, nil
+}
+
+func (l CSVLimits) of(group string) int {
+	if n, ok := l.Groups[strings.ToLower(group)]; ok {
+		return n
+	}
+	return l.Default
+}
+
+// ParseCSVComma
+// A single-character delimiter, where "\t" and "tab" mean a tab.
+func ParseCSVComma(s string) (rune, error) {
+	switch s {
+	case `\t`, "tab":
+		return '\t', nil
+	}
+
+	r := []rune(s)
+	if len(r) != 1 || r[0] == '"' || r[0] == '\r' || r[0] == '\n' {
+		return 0, fmt.Errorf("invalid CSV delimiter %q, expecting a single character", s)
+	}
+	return r[0], nil
+}
+
+// TextVCSV
+// Headers in rows, instead of a single row.
+func (s *Specs) TextVCSV(opts CSVOptions) (string, error) {
+	if opts.Layout == CSVLong {
+		return "", fmt.Errorf("the long layout is for CSV only")
+	}
+
+	tbl := s.csvTable(opts)
+	if opts.NoHeader {
+		for i := range tbl {
+			tbl[i] = tbl[i][1:]
+		}
+	}
+
+	return writeCSV(tbl, opts)
+}
+
+// TextCSV
+// Headers in a single row, values in the next one,
+// or the next ones in the long layout.
+func (s *Specs) TextCSV(opts CSVOptions) (string, error) {
+	var rows [][]string
+
+	switch opts.Layout {
+	case CSVLong:
+		rows = s.csvLong()
+	default:
+		tbl := s.csvTable(opts)
+
+		rows = [][]string{make([]string, len(tbl)), make([]string, len(tbl))}
+		for j, col := range tbl {
+			rows[0][j], rows[1][j] = col[0], col[1]
+		}
+	}
+
+	if opts.NoHeader {
+		rows = rows[1:]
+	}
+
+	return writeCSV(rows, opts)
+}
+
+// writeCSV
+// Quoting and escaping are left to encoding/csv,
+// so delimiters, quotes and line breaks in values come out right.
+func writeCSV(rows [][]string, opts CSVOptions) (string, error) {
+	var buf strings.Builder
+
+	if opts.BOM {
+		buf.WriteString("\uFEFF")
+	}
+
+	w := csv.NewWriter(&buf)
+	if opts.Comma != 0 {
+		w.Comma = opts.Comma
+	}
+	w.UseCRLF = opts.CRLF
+
+	if err := w.WriteAll(rows); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// csvTable
+// Header and value pairs, see Table.
+func (s *Specs) csvTable(opts CSVOptions) [][]string {
+	if opts.Layout != CSVFixed {
+		return s.Table(s, false, 0)
+	}
+
+	var z [][]string
+	for _, f := range s.schema() {
+		z = append(z, csvFixed(f.name, f.t, f.v, "", opts.Limits)...)
+	}
+	return z
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Fixed and long layouts
+////////////////////////////////////////////////////////////////////////////////
+
+type csvField struct {
+	name string
+	t    reflect.Type
+	v    reflect.Value // invalid if not collected
+}
+
+// schema
+// Every section there is, collected or not, so the columns don't depend
+// on the machine, then Diagnostics, named as in Table.
+func (s *Specs) schema() (fields []csvField) {
+	if OutputUnits != UnitsDefault {
+		fields = append(fields, csvField{"Units",
+			reflect.TypeOf(OutputUnits), reflect.ValueOf(OutputUnits)})
+	}
+
+	for _, sec := range sections {
+		if sec.Hidden || sec.New == nil {
+			continue
+		}
+
+		f := csvField{name: sec.Name, t: reflect.TypeOf(sec.New())}
+		if n := s.node(sec.Key); n != nil {
+			f.v = reflect.ValueOf(n)
+		}
+		fields = append(fields, f)
+	}
+
+	return append(fields, csvField{"Diagnostics",
+		reflect.TypeOf(s.Diagnostics), reflect.ValueOf(s.Diagnostics)})
+}
+
+// csvFixed
+// Header and value pairs by type rather than by value,
+// named as in Table, empty where there's no value.
+func csvFixed(name string, t reflect.Type, v reflect.Value, prefix string, limits CSVLimits) (z [][]string) {
+	t, v = csvDeref(t, v)
+
+	switch t.Kind() {
+	case reflect.Struct:
+		z = append(z, csvFixedStruct(t, v, name+" ", limits)...)
+
+	case reflect.Slice:
+		e := t.Elem()
+		for j := range limits.of(name) {
+			var ev reflect.Value
+			if v.IsValid() && j < v.Len() {
+				ev = v.Index(j)
+			}
+
+			label := fmt.Sprintf("%s%d", e.Name(), j)
+			if componentLabel.MatchString(prefix) {
+				label = prefix + label
+			}
+			if et, _ := csvDeref(e, reflect.Value{}); et.Kind() != reflect.Struct {
+				z = append(z, csvFixed(label, e, ev, prefix, limits)...)
+				continue
+			}
+			et, ev := csvDeref(e, ev)
+			z = append(z, csvFixedStruct(et, ev, label+" ", limits)...)
+		}
+
+	default:
+		var val string
+		if v.IsValid() {
+			val = fmt.Sprintf("%v", v.Interface())
+		}
+		z = append(z, []string{prefix + name, val})
+	}
+
+	return z
+}
+
+func csvFixedStruct(t reflect.Type, v reflect.Value, prefix string, limits CSVLimits) (z [][]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+
+		z = append(z, csvFixed(tableKey(f), f.Type, fv, prefix, limits)...)
+	}
+	return z
+}
+
+func csvDeref(t reflect.Type, v reflect.Value) (reflect.Type, reflect.Value) {
+	if t.Kind() != reflect.Ptr {
+		return t, v
+	}
+	if v.IsValid() {
+		if v.IsNil() {
+			v = reflect.Value{}
+		} else {
+			v = v.Elem()
+		}
+	}
+	return t.Elem(), v
+}
+
+// csvLong
+// A header, then a row per section and per component,
+// e.g., Windows, CPU 0, Memory, DIMM 0, DIMM 1, ...
+// Columns are named by component and field, e.g., DIMM Capacity,
+// so fields of the same name in different units stay apart,
+// and nested components name their parent, e.g., Disk0 for its volumes,
+// since their index restarts under each.
+func (s *Specs) csvLong() [][]string {
+	var deviceName string
+	if w, ok := s.node("windows").(*Windows); ok {
+		deviceName = w.CSName
+	}
+
+	// Every field of every section and component, in order of appearance.
+	header := []string{"DeviceName", "Component", "Index", "Parent"}
+	index := map[string]int{"Windows DeviceName": 0} // the same
+
+	// As a column, rather than a row of its own.
+	if OutputUnits != UnitsDefault {
+		header = append(header, "Units")
+	}
+
+	var columns func(component string, t reflect.Type)
+	columns = func(component string, t reflect.Type) {
+		t, _ = csvDeref(t, reflect.Value{})
+		switch t.Kind() {
+		case reflect.Slice:
+			e, _ := csvDeref(t.Elem(), reflect.Value{})
+			columns(e.Name(), e)
+		case reflect.Struct:
+			for i := range t.NumField() {
+				f := t.Field(i)
+				ft, _ := csvDeref(f.Type, reflect.Value{})
+				switch ft.Kind() {
+				case reflect.Slice, reflect.Struct:
+					columns(ft.Name(), ft)
+				default:
+					col := component + " " + tableKey(f)
+					if _, ok := index[col]; !ok {
+						index[col] = len(header)
+						header = append(header, col)
+					}
+				}
+			}
+		}
+	}
+
+	fields := s.schema()
+	for _, f := range fields {
+		if f.name != "Units" {
+			columns(f.name, f.t)
+		}
+	}
+
+	rows := [][]string{header}
+
+	var add func(component, idx, parent string, v reflect.Value)
+	add = func(component, idx, parent string, v reflect.Value) {
+		_, v = csvDeref(v.Type(), v)
+		if !v.IsValid() {
+			return
+		}
+
+		switch v.Kind() {
+		case reflect.Slice:
+			for j := range v.Len() {
+				add(v.Type().Elem().Name(), strconv.Itoa(j), parent, v.Index(j))
+			}
+
+		case reflect.Struct:
+			row := make([]string, len(header))
+			row[0], row[1], row[2], row[3] = deviceName, component, idx, parent
+			if OutputUnits != UnitsDefault {
+				row[4] = string(OutputUnits)
+			}
+
+			var nested []reflect.Value
+			for i := range v.NumField() {
+				fv := v.Field(i)
+				_, dv := csvDeref(fv.Type(), fv)
+				switch {
+				case !dv.IsValid():
+				case dv.Kind() == reflect.Slice, dv.Kind() == reflect.Struct:
+					nested = append(nested, fv)
+				default:
+					row[index[component+" "+tableKey(v.Type().Field(i))]] = fmt.Sprintf("%v", dv.Interface())
+				}
+			}
+			rows = append(rows, row)
+
+			for _, n := range nested {
+				add(n.Type().Name(), "", component+idx, n)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if f.v.IsValid() && f.name != "Units" {
+			add(f.name, "", "", f.v)
+		}
+	}
+
+	return rows
+}
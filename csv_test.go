@@ -0,0 +1,221 @@
+//go:build cli
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Values a spreadsheet would trip on, if not quoted right.
+var csvTricky = map[string]string{
+	"quotes":   `Latitude 7420 "Pro"`,
+	"commas":   "Contoso, Ltd.",
+	"newlines": "first line\nsecond line\n",
+	"formula":  "=HYPERLINK(\"http://example.com\",\"x\")",
+	"unicode":  "Ünïcødé 日本語 ✓",
+}
+
+// csvSpecs
+// The replay fixture, with the tricky values in a section, a component
+// and a nested component, the device name included.
+func csvSpecs(t *testing.T) *Specs {
+	t.Helper()
+
+	src, err := ReplaySources("testdata/replay/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Specs{}
+	if err := s.Collect(context.Background(), src, CollectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := s.node("windows").(*Windows)
+	w.CSName = csvTricky["commas"]
+	w.Caption = csvTricky["newlines"]
+	w.RegisteredUser = csvTricky["unicode"]
+
+	programs := *s.node("software").(*InstalledSoftware)
+	programs[0].Publisher = csvTricky["formula"]
+	programs[1].Name = csvTricky["quotes"]
+
+	disks := *s.node("disks").(*Disks)
+	disks[0].Volumes[0].Label = csvTricky["unicode"] + ", " + csvTricky["quotes"]
+
+	return s
+}
+
+// readCSV
+// Undo the options, then read it back as any CSV reader would.
+func readCSV(t *testing.T, text string, opts CSVOptions) [][]string {
+	t.Helper()
+
+	if opts.BOM != strings.HasPrefix(text, "\uFEFF") {
+		t.Fatalf("BOM = %v, want %v", !opts.BOM, opts.BOM)
+	}
+	text = strings.TrimPrefix(text, "\uFEFF")
+
+	if !strings.HasSuffix(text, map[bool]string{false: "\n", true: "\r\n"}[opts.CRLF]) {
+		t.Fatalf("CRLF = %v, but the text ends with %q", opts.CRLF, text[max(0, len(text)-2):])
+	}
+	if !opts.CRLF && strings.Contains(text, "\r") {
+		t.Fatal("CR in a file with LF line endings")
+	}
+
+	r := csv.NewReader(strings.NewReader(text))
+	if opts.Comma != 0 {
+		r.Comma = opts.Comma
+	}
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	s := csvSpecs(t)
+
+	type layout struct {
+		name  string
+		write func(CSVOptions) (string, error)
+		want  func(CSVOptions) [][]string
+		opts  CSVOptions
+	}
+
+	// Columns as pairs of header and value, into rows.
+	rowsOf := func(tbl [][]string) [][]string {
+		rows := [][]string{{}, {}}
+		for _, col := range tbl {
+			rows[0], rows[1] = append(rows[0], col[0]), append(rows[1], col[1])
+		}
+		return rows
+	}
+	limits := CSVLimits{Default: DefaultCSVLimit, Groups: map[string]int{"installedsoftware": 3}}
+
+	layouts := []layout{
+		{"wide", s.TextCSV, func(o CSVOptions) [][]string { return rowsOf(s.csvTable(o)) }, CSVOptions{}},
+		{"fixed", s.TextCSV, func(o CSVOptions) [][]string { return rowsOf(s.csvTable(o)) },
+			CSVOptions{Layout: CSVFixed, Limits: limits}},
+		{"long", s.TextCSV, func(CSVOptions) [][]string { return s.csvLong() }, CSVOptions{Layout: CSVLong}},
+		{"vertical", s.TextVCSV, func(o CSVOptions) [][]string { return s.csvTable(o) }, CSVOptions{}},
+	}
+
+	for _, l := range layouts {
+		for _, variant := range []struct {
+			name string
+			set  func(*CSVOptions)
+		}{
+			{"LF", func(*CSVOptions) {}},
+			{"BOM CRLF", func(o *CSVOptions) { o.BOM, o.CRLF = true, true }},
+			{"BOM LF", func(o *CSVOptions) { o.BOM = true }},
+			{"CRLF semicolon", func(o *CSVOptions) { o.CRLF, o.Comma = true, ';' }},
+		} {
+			t.Run(l.name+" "+variant.name, func(t *testing.T) {
+				opts := l.opts
+				variant.set(&opts)
+
+				text, err := l.write(opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := readCSV(t, text, opts)
+
+				// A CSV reader reads a line break in a value as LF, whatever was written.
+				if want := l.want(opts); !reflect.DeepEqual(got, want) {
+					t.Errorf("read back differs from what was written")
+					for i := range min(len(got), len(want)) {
+						if !slices.Equal(got[i], want[i]) {
+							t.Errorf("row %d:\n got %q\nwant %q", i, got[i], want[i])
+							break
+						}
+					}
+				}
+
+				// Every tricky value made it through.
+				all := fmt.Sprint(got)
+				for name, v := range csvTricky {
+					if !strings.Contains(all, v) {
+						t.Errorf("%s value %q lost", name, v)
+					}
+				}
+			})
+		}
+	}
+}
+
+// Appending a headerless row from another machine lines up with the header.
+func TestCSVFixedAppend(t *testing.T) {
+	s := csvSpecs(t)
+	opts := CSVOptions{Layout: CSVFixed, Limits: CSVLimits{Default: 2}, BOM: true, CRLF: true}
+
+	head, err := s.TextCSV(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := csvSpecs(t)
+	*other.node("memory").(*Memory) = Memory{}
+	opts.NoHeader, opts.BOM = true, false
+	tail, err := other.TextCSV(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts.BOM = true
+	rows := readCSV(t, head+tail, opts)
+	if len(rows) != 3 {
+		t.Fatalf("%d rows, want a header and 2 machines", len(rows))
+	}
+	for i, row := range rows {
+		if len(row) != len(rows[0]) {
+			t.Errorf("row %d has %d columns, want %d", i, len(row), len(rows[0]))
+		}
+	}
+}
+
+// Volumes name their disk, and same-named fields of different components,
+// in different units, stay in columns of their own.
+func TestCSVLong(t *testing.T) {
+	rows := csvSpecs(t).csvLong()
+	header := rows[0]
+
+	col := func(name string) int {
+		t.Helper()
+		i := slices.Index(header, name)
+		if i < 0 {
+			t.Fatalf("no %s column in %q", name, header)
+		}
+		return i
+	}
+	component, index, parent := col("Component"), col("Index"), col("Parent")
+	dimm, volume := col("DIMM Capacity"), col("Volume Capacity")
+
+	var volumes []string
+	for _, row := range rows[1:] {
+		switch row[component] {
+		case "Volume":
+			volumes = append(volumes, row[parent]+" "+row[index])
+			if row[volume] == "" || row[dimm] != "" {
+				t.Errorf("volume capacity in %q, DIMM capacity in %q", row[volume], row[dimm])
+			}
+		case "DIMM":
+			if row[parent] != "Memory" || row[dimm] == "" || row[volume] != "" {
+				t.Errorf("DIMM row %q", row)
+			}
+		}
+	}
+	if want := []string{"Disk0 0", "Disk1 0"}; !slices.Equal(volumes, want) {
+		t.Errorf("volumes = %q, want %q", volumes, want)
+	}
+
+	if slices.Contains(header, "Windows DeviceName") || rows[1][0] == "" {
+		t.Errorf("DeviceName isn't the first column of every row: %q", rows[1])
+	}
+}
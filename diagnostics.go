@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// Diagnostics
+// What went wrong while collecting, section by section.
+// A failed section is still reported, only empty or partially N/A.
+type Diagnostics []Diagnostic
+
+type Diagnostic struct {
+	Section  string
+	Severity string
+	Message  string
+}
+
+const (
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityTimeout  = "timeout"  // section or overall deadline exceeded
+	SeverityCanceled = "canceled" // stopped by the caller or a failing sibling
+)
+
+// Warning
+// A non-fatal collection error, e.g., a single missing registry value.
+// The section is complete except for the values reported as N/A.
+type Warning struct {
+	Err error
+}
+
+func (w *Warning) Error() string {
+	return w.Err.Error()
+}
+
+func (w *Warning) Unwrap() error {
+	return w.Err
+}
+
+func warn(err error) error {
+	return &Warning{Err: err}
+}
+
+// isWarning
+// Whether err, joined or not, only consists of warnings.
+func isWarning(err error) bool {
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range j.Unwrap() {
+			if !isWarning(e) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var w *Warning
+	return errors.As(err, &w)
+}
+
+// add
+// Record err for section, one entry per joined error.
+func (d *Diagnostics) add(section string, err error) {
+	if err == nil {
+		return
+	}
+
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range j.Unwrap() {
+			d.add(section, e)
+		}
+		return
+	}
+
+	severity := SeverityError
+	var w *Warning
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		severity = SeverityTimeout
+	case errors.Is(err, context.Canceled):
+		severity = SeverityCanceled
+	case errors.As(err, &w):
+		severity = SeverityWarning
+	}
+
+	*d = append(*d, Diagnostic{
+		Section:  section,
+		Severity: severity,
+		Message:  err.Error(),
+	})
+}
+
+// TimedOut
+// Sections that ran out of time, in report order, each listed once.
+func (d Diagnostics) TimedOut() (sections []string) {
+	for _, v := range d {
+		if v.Severity == SeverityTimeout && !slices.Contains(sections, v.Section) {
+			sections = append(sections, v.Section)
+		}
+	}
+	return sections
+}
@@ -0,0 +1,410 @@
+//go:build cli
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ChangeKind
+// What happened to a section or component between two reports.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change
+// A section or component added, removed or changed, see Diff.
+// Component is empty for the section's own fields, e.g., Memory TotalSize,
+// otherwise it's the component's type and match key, e.g., DIMM ChannelA-DIMM0.
+// Fields are the ones changed, or every one of an added or removed component.
+type Change struct {
+	Section   string
+	Component string `json:",omitempty"`
+	Kind      ChangeKind
+	Fields    []FieldChange `json:",omitempty"`
+}
+
+type FieldChange struct {
+	Field string
+	Old   string `json:",omitempty"`
+	New   string `json:",omitempty"`
+}
+
+type Changes []Change
+
+// componentKeys
+// The field components are matched by, across reports,
+// so a DIMM moved to another slot is removed from one and added to another,
+// and a program updated is changed, not removed and added.
+// Components without one are matched by position, e.g., CPU0.
+var componentKeys = map[reflect.Type]string{
+	reflect.TypeFor[DIMM]():       "DeviceLocator",
+	reflect.TypeFor[Disk]():       "SerialNumber",
+	reflect.TypeFor[Monitor]():    "SerialNumber",
+	reflect.TypeFor[NetAdapter](): "MACAddress",
+	reflect.TypeFor[Program]():    "Name",
+	reflect.TypeFor[Update]():     "HotFixID",
+	reflect.TypeFor[Volume]():     "DriveLetter",
+}
+
+// DiffOptions
+// Zero value compares what only changes when the machine does.
+type DiffOptions struct {
+	// Compare fields tagged diff:"volatile" as well, the ones that change
+	// on their own between runs, e.g., disk temperature and free space.
+	Volatile bool
+
+	rounded []Units // of either report, see diffField
+}
+
+// Diff
+// Compare two reports of the same machine, section by section,
+// e.g., to spot hardware swapped between audits.
+// Sections collected in only one of them are added or removed as a whole.
+func Diff(old, new *Specs, opts DiffOptions) (z Changes) {
+	for _, s := range []*Specs{old, new} {
+		if s.units == UnitsDefault || s.units == UnitsHuman {
+			opts.rounded = append(opts.rounded, s.units)
+		}
+	}
+
+	for _, sec := range sections {
+		if sec.Hidden {
+			continue
+		}
+
+		o, n := old.node(sec.Key), new.node(sec.Key)
+		switch {
+		case o == nil && n == nil:
+		case o == nil:
+			z = append(z, Change{Section: sec.Name, Kind: Added})
+		case n == nil:
+			z = append(z, Change{Section: sec.Name, Kind: Removed})
+		default:
+			z = append(z, diffNode(sec.Name, reflect.ValueOf(o), reflect.ValueOf(n), opts)...)
+		}
+	}
+
+	return z
+}
+
+// diffNode
+// Changes of a section: its own fields, then its components,
+// e.g., Memory TotalSize, then its DIMMs.
+func diffNode(section string, o, n reflect.Value, opts DiffOptions) (z Changes) {
+	o, n = reflect.Indirect(o), reflect.Indirect(n)
+
+	if o.Kind() == reflect.Slice {
+		return diffComponents(section, o, n, opts)
+	}
+
+	own := Change{Section: section, Kind: Changed}
+	for i := range o.NumField() {
+		f := o.Type().Field(i)
+
+		switch o.Field(i).Kind() {
+		case reflect.Slice:
+			z = append(z, diffComponents(section, o.Field(i), n.Field(i), opts)...)
+		default:
+			if fc, ok := diffField(f, o.Field(i), n.Field(i), opts); ok {
+				own.Fields = append(own.Fields, fc)
+			}
+		}
+	}
+
+	if len(own.Fields) > 0 {
+		z = append(Changes{own}, z...)
+	}
+	return z
+}
+
+// diffComponents
+// Components matched by componentKeys, in the order of the newer report,
+// then the removed ones in the order of the older.
+func diffComponents(section string, o, n reflect.Value, opts DiffOptions) (z Changes) {
+	olds, news := componentMap(o), componentMap(n)
+	seen := map[string]bool{}
+
+	for _, k := range news.keys {
+		nv := news.vals[k]
+		seen[k] = true
+
+		ov, ok := olds.vals[k]
+		if !ok {
+			z = append(z, Change{Section: section, Component: k, Kind: Added,
+				Fields: componentFields(reflect.Value{}, nv)})
+			z = append(z, nestedComponents(section, reflect.Value{}, nv, opts)...)
+			continue
+		}
+
+		c := Change{Section: section, Component: k, Kind: Changed}
+		for i := range nv.NumField() {
+			if nv.Field(i).Kind() == reflect.Slice {
+				continue
+			}
+			if fc, ok := diffField(nv.Type().Field(i), ov.Field(i), nv.Field(i), opts); ok {
+				c.Fields = append(c.Fields, fc)
+			}
+		}
+		if len(c.Fields) > 0 {
+			z = append(z, c)
+		}
+		z = append(z, nestedComponents(section, ov, nv, opts)...)
+	}
+
+	for _, k := range olds.keys {
+		if !seen[k] {
+			z = append(z, Change{Section: section, Component: k, Kind: Removed,
+				Fields: componentFields(olds.vals[k], reflect.Value{})})
+			z = append(z, nestedComponents(section, olds.vals[k], reflect.Value{}, opts)...)
+		}
+	}
+
+	return z
+}
+
+// nestedComponents
+// Changes of the components of a component, e.g., the volumes of a disk,
+// all of them added or removed along with it.
+func nestedComponents(section string, o, n reflect.Value, opts DiffOptions) (z Changes) {
+	v := o
+	if !v.IsValid() {
+		v = n
+	}
+
+	for i := range v.NumField() {
+		if v.Field(i).Kind() != reflect.Slice {
+			continue
+		}
+
+		of, nf := reflect.Zero(v.Field(i).Type()), reflect.Zero(v.Field(i).Type())
+		if o.IsValid() {
+			of = o.Field(i)
+		}
+		if n.IsValid() {
+			nf = n.Field(i)
+		}
+		z = append(z, diffComponents(section, of, nf, opts)...)
+	}
+
+	return z
+}
+
+type components struct {
+	keys []string
+	vals map[string]reflect.Value
+}
+
+// componentMap
+// Components by type and match key, e.g., "Disk S3Z9NB0K123456",
+// or position, e.g., "CPU0".
+// Duplicate or missing keys fall back to the position.
+func componentMap(v reflect.Value) (c components) {
+	c.vals = map[string]reflect.Value{}
+
+	for i := range v.Len() {
+		e := v.Index(i)
+		t := e.Type()
+
+		k := t.Name() + strconv.Itoa(i)
+		if f, ok := componentKeys[t]; ok {
+			if id := e.FieldByName(f).String(); known(id) {
+				if _, dup := c.vals[t.Name()+" "+id]; !dup {
+					k = t.Name() + " " + id
+				}
+			}
+		}
+
+		c.keys = append(c.keys, k)
+		c.vals[k] = e
+	}
+
+	return c
+}
+
+// componentFields
+// Every field of an added or removed component.
+func componentFields(o, n reflect.Value) (z []FieldChange) {
+	v := o
+	if !v.IsValid() {
+		v = n
+	}
+
+	for i := range v.NumField() {
+		if v.Field(i).Kind() == reflect.Slice {
+			continue // see nestedComponents
+		}
+
+		fc := FieldChange{Field: tableKey(v.Type().Field(i))}
+		if o.IsValid() {
+			fc.Old = fmt.Sprint(o.Field(i).Interface())
+		}
+		if n.IsValid() {
+			fc.New = fmt.Sprint(n.Field(i).Interface())
+		}
+		z = append(z, fc)
+	}
+
+	return z
+}
+
+// diffField
+// Values are compared as stored, and written as in the text formats,
+// so a DIMM swapped for a slightly smaller one shows, even if as 8 -> 8.
+// A report saved in rounded units, i.e., the default or human, only tells
+// as much, so values that read the same in its units are no change,
+// e.g., 512 GB against 512110190592 B.
+// Volatile fields aren't compared, unless opts.Volatile.
+func diffField(f reflect.StructField, o, n reflect.Value, opts DiffOptions) (FieldChange, bool) {
+	if f.Tag.Get("diff") == "volatile" && !opts.Volatile {
+		return FieldChange{}, false
+	}
+	if reflect.DeepEqual(o.Interface(), n.Interface()) {
+		return FieldChange{}, false
+	}
+	for _, u := range opts.rounded {
+		if sameIn(o, n, u) {
+			return FieldChange{}, false
+		}
+	}
+
+	return FieldChange{
+		Field: tableKey(f),
+		Old:   fmt.Sprint(o.Interface()),
+		New:   fmt.Sprint(n.Interface()),
+	}, true
+}
+
+// sameIn
+// Whether o and n are written alike in u.
+// OutputUnits is package-wide, so it's swapped for the while.
+func sameIn(o, n reflect.Value, u Units) bool {
+	defer func(v Units) { OutputUnits = v }(OutputUnits)
+	OutputUnits = u
+
+	return fmt.Sprint(o.Interface()) == fmt.Sprint(n.Interface())
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Diff outputs
+////////////////////////////////////////////////////////////////////////////////
+
+var changeSigns = map[ChangeKind]string{Added: "+", Removed: "-", Changed: "~"}
+
+// Text
+// A line per change, + added, - removed, ~ changed,
+// followed by the fields, indented.
+func (z Changes) Text() (out string) {
+	for _, c := range z {
+		out += changeSigns[c.Kind] + " " + c.Section
+		if c.Component != "" {
+			out += " " + c.Component
+		}
+		out += "\n"
+
+		for _, f := range c.Fields {
+			switch c.Kind {
+			case Added:
+				out += fmt.Sprintf("    %-20s: %s\n", f.Field, f.New)
+			case Removed:
+				out += fmt.Sprintf("    %-20s: %s\n", f.Field, f.Old)
+			default:
+				out += fmt.Sprintf("    %-20s: %s -> %s\n", f.Field, f.Old, f.New)
+			}
+		}
+	}
+
+	return out
+}
+
+func (z Changes) JSON() (string, error) {
+	if z == nil {
+		z = Changes{}
+	}
+
+	jsonData, err := json.Marshal(z)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+// HTML
+// A page with a table row per changed field, titled with both reports.
+func (z Changes) HTML(oldName, newName string) (string, error) {
+	var buf bytes.Buffer
+	err := diffHTMLTmpl.Execute(&buf, map[string]any{
+		"changes":   z,
+		"old":       oldName,
+		"new":       newName,
+		"signs":     changeSigns,
+		"version":   Version,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+	return buf.String(), err
+}
+
+var diffHTMLTmpl = template.Must(template.New("diff").Parse(`<!DOCTYPE html>
+<html lang="en">
+	<head>
+		<meta charset="UTF-8" />
+		<meta name="viewport" content="width=device-width, initial-scale=1" />
+		<title>Winspecter diff</title>
+		<style>
+			body { font-family: sans-serif; margin: 2em; }
+			table { border-collapse: collapse; }
+			th, td { border: 1px solid #ccc; padding: .3em .6em; text-align: left; vertical-align: top; }
+			th { background: #eee; }
+			.added { background: #e6ffec; }
+			.removed { background: #ffebe9; }
+			.changed { background: #fff8c5; }
+			footer { margin-top: 1em; color: #666; font-size: smaller; }
+		</style>
+	</head>
+	<body>
+		<h1>Win Specs Reporter diff</h1>
+		<p><del>{{.old}}</del> &rarr; <ins>{{.new}}</ins></p>
+{{- if .changes}}
+		<table>
+			<thead>
+				<tr><th></th><th>Section</th><th>Component</th><th>Field</th><th>Old</th><th>New</th></tr>
+			</thead>
+			<tbody>
+{{- range .changes}}
+{{- $c := .}}
+				<tr class="{{.Kind}}">
+					<td rowspan="{{or (len .Fields) 1}}">{{index $.signs .Kind}}</td>
+					<td rowspan="{{or (len .Fields) 1}}">{{.Section}}</td>
+					<td rowspan="{{or (len .Fields) 1}}">{{.Component}}</td>
+{{- range $i, $f := .Fields}}
+{{- if $i}}
+				<tr class="{{$c.Kind}}">
+{{- end}}
+					<td>{{$f.Field}}</td><td>{{$f.Old}}</td><td>{{$f.New}}</td>
+				</tr>
+{{- else}}
+					<td></td><td></td><td></td>
+				</tr>
+{{- end}}
+{{- end}}
+			</tbody>
+		</table>
+{{- else}}
+		<p>No changes.</p>
+{{- end}}
+		<footer>
+			<p>{{len .changes}} changes, by Winspecter v{{.version}} at {{.timestamp}}.</p>
+		</footer>
+	</body>
+</html>
+`))
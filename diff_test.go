@@ -0,0 +1,95 @@
+//go:build cli
+
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// Two runs on an unchanged machine only differ in volatile fields.
+func TestDiffVolatile(t *testing.T) {
+	src, err := ReplaySources("testdata/replay/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var old, new Specs
+	for _, s := range []*Specs{&old, &new} {
+		if err := s.Collect(context.Background(), src, CollectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	disk := &(*new.node("disks").(*Disks))[0]
+	disk.Temperature, disk.PowerOnHours, disk.Wear, disk.UncorrectedErrors = "45 °C", "4260", "3%", "1"
+	disk.Volumes[0].FreeSpace -= 7
+
+	if z := Diff(&old, &new, DiffOptions{}); len(z) != 0 {
+		t.Errorf("unchanged machine, changes:\n%s", z.Text())
+	}
+
+	z := Diff(&old, &new, DiffOptions{Volatile: true})
+	var fields []string
+	for _, c := range z {
+		for _, f := range c.Fields {
+			fields = append(fields, c.Component+" "+f.Field)
+		}
+	}
+	if len(fields) != 5 {
+		t.Errorf("-volatile, %d changed fields, want 5: %q", len(fields), fields)
+	}
+
+	// Anything else still is a change.
+	disk.FirmwareVersion = "6L7QCXY7"
+	if z := Diff(&old, &new, DiffOptions{}); len(z) != 1 || len(z[0].Fields) != 1 || z[0].Fields[0].Field != "FirmwareVersion" {
+		t.Errorf("firmware updated, changes:\n%s", z.Text())
+	}
+}
+
+// A report saved in the default units against one in raw ones
+// only differs where the rounded one can tell.
+func TestDiffUnits(t *testing.T) {
+	src, err := ReplaySources("testdata/replay/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collected Specs
+	if err := collected.Collect(context.Background(), src, CollectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(u Units) { OutputUnits = u }(OutputUnits)
+	saved := func(u Units) *Specs {
+		t.Helper()
+		OutputUnits = u
+		doc, err := collected.JSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s Specs
+		if err := s.Load([]byte(doc), "json"); err != nil {
+			t.Fatal(err)
+		}
+		return &s
+	}
+	rounded, raw := saved(UnitsDefault), saved(UnitsRaw)
+	OutputUnits = UnitsDefault
+
+	for _, c := range []struct {
+		name     string
+		old, new *Specs
+	}{
+		{"default -> raw", rounded, raw},
+		{"raw -> default", raw, rounded},
+	} {
+		if z := Diff(c.old, c.new, DiffOptions{}); len(z) != 0 {
+			t.Errorf("%s, changes:\n%s", c.name, z.Text())
+		}
+	}
+
+	// A disk of another size still is a change.
+	(*raw.node("disks").(*Disks))[0].Size *= 2
+	if z := Diff(rounded, raw, DiffOptions{}); len(z) != 1 || z[0].Fields[0].Field != "Size" {
+		t.Errorf("disk swapped, changes:\n%s", z.Text())
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Storage Management API, the one Get-PhysicalDisk reads, Windows 8 and later.
+const storageNamespace = `root\Microsoft\Windows\Storage`
+
+// MSFT_PhysicalDisk enumerations, the values Windows knows of.
+var (
+	mediaTypes = map[uint64]string{0: "Unspecified", 3: "HDD", 4: "SSD", 5: "SCM"}
+	busTypes   = map[uint64]string{
+		0: "Unknown", 1: "SCSI", 2: "ATAPI", 3: "ATA", 4: "IEEE 1394", 5: "SSA",
+		6: "Fibre Channel", 7: "USB", 8: "RAID", 9: "iSCSI", 10: "SAS", 11: "SATA",
+		12: "SD", 13: "MMC", 14: "Virtual", 15: "File Backed Virtual",
+		16: "Storage Spaces", 17: "NVMe", 18: "SCM", 19: "UFS",
+	}
+	healthStatus = map[uint64]string{0: "Healthy", 1: "Warning", 2: "Unhealthy", 5: "Unknown"}
+)
+
+// SMART-style thresholds, past which a disk is worth replacing.
+const (
+	wearLimit        = 90 // % of rated endurance used
+	temperatureLimit = 70 // °C
+)
+
+// collectHealth
+// Media, bus, firmware and health of each disk, by its Win32_DiskDrive index,
+// then wear and error counters, which need admin rights.
+// Nothing here fails the disks, it's only N/A with a warning.
+func (d *Disks) collectHealth(ctx context.Context, q Querier, indexes []uint64) error {
+	for i := range *d {
+		(*d)[i].setHealthNA()
+	}
+
+	var physical []struct {
+		DeviceId        string
+		MediaType       uint64
+		BusType         uint64
+		FirmwareVersion string
+		HealthStatus    uint64
+	}
+
+	if err := query(ctx, q,
+		"SELECT DeviceId, MediaType, BusType, FirmwareVersion, HealthStatus FROM MSFT_PhysicalDisk",
+		&physical, nil, storageNamespace); err != nil {
+		return warn(fmt.Errorf("disk health: %w", err))
+	}
+
+	// MSFT_PhysicalDisk.DeviceId is Win32_DiskDrive.Index, as a string.
+	byID := map[string]*Disk{}
+	for i, index := range indexes {
+		byID[strconv.FormatUint(index, 10)] = &(*d)[i]
+	}
+
+	for _, p := range physical {
+		disk, ok := byID[p.DeviceId]
+		if !ok {
+			continue
+		}
+		disk.MediaType = cmp.Or(mediaTypes[p.MediaType], "N/A")
+		disk.BusType = cmp.Or(busTypes[p.BusType], "N/A")
+		disk.FirmwareVersion = cmp.Or(strings.TrimSpace(p.FirmwareVersion), "N/A")
+		disk.HealthStatus = cmp.Or(healthStatus[p.HealthStatus], "N/A")
+	}
+
+	warnCounters := collectReliability(ctx, q, byID)
+
+	for i := range *d {
+		(*d)[i].SMARTWarning = (*d)[i].smartWarning()
+	}
+
+	return warnCounters
+}
+
+// collectReliability
+// The counters of MSFT_StorageReliabilityCounter, if allowed to read them.
+// Pointers, as drives report only some of them, e.g., no wear for HDDs.
+func collectReliability(ctx context.Context, q Querier, byID map[string]*Disk) error {
+	var v []struct {
+		DeviceId               string
+		Wear                   *uint64
+		Temperature            *uint64
+		PowerOnHours           *uint64
+		ReadErrorsUncorrected  *uint64
+		WriteErrorsUncorrected *uint64
+	}
+
+	if err := query(ctx, q,
+		"SELECT DeviceId, Wear, Temperature, PowerOnHours, ReadErrorsUncorrected, WriteErrorsUncorrected "+
+			"FROM MSFT_StorageReliabilityCounter",
+		&v, nil, storageNamespace); err != nil {
+		return warn(fmt.Errorf("disk reliability counters: %w", err))
+	}
+
+	for _, c := range v {
+		disk, ok := byID[c.DeviceId]
+		if !ok {
+			continue
+		}
+		if c.Wear != nil {
+			disk.Wear = fmt.Sprintf("%d%%", *c.Wear)
+		}
+		// Zero is a drive not telling, not one freezing.
+		if c.Temperature != nil && *c.Temperature > 0 {
+			disk.Temperature = fmt.Sprintf("%d °C", *c.Temperature)
+		}
+		if c.PowerOnHours != nil {
+			disk.PowerOnHours = strconv.FormatUint(*c.PowerOnHours, 10)
+		}
+		if c.ReadErrorsUncorrected != nil || c.WriteErrorsUncorrected != nil {
+			var n uint64
+			for _, e := range []*uint64{c.ReadErrorsUncorrected, c.WriteErrorsUncorrected} {
+				if e != nil {
+					n += *e
+				}
+			}
+			disk.UncorrectedErrors = strconv.FormatUint(n, 10)
+		}
+	}
+
+	return nil
+}
+
+func (disk *Disk) setHealthNA() {
+	for _, f := range []*string{&disk.MediaType, &disk.BusType, &disk.FirmwareVersion,
+		&disk.HealthStatus, &disk.Wear, &disk.Temperature, &disk.PowerOnHours,
+		&disk.UncorrectedErrors, &disk.SMARTWarning} {
+		if *f == "" {
+			*f = "N/A"
+		}
+	}
+}
+
+// smartWarning
+// Why the disk may be failing, e.g., "health unhealthy; 95% worn",
+// None if nothing says so, N/A if nothing tells at all.
+func (disk *Disk) smartWarning() string {
+	var reasons []string
+
+	switch disk.HealthStatus {
+	case "Warning", "Unhealthy":
+		reasons = append(reasons, "health "+strings.ToLower(disk.HealthStatus))
+	}
+	if n, err := strconv.ParseUint(strings.TrimSuffix(disk.Wear, "%"), 10, 64); err == nil && n >= wearLimit {
+		reasons = append(reasons, disk.Wear+" worn")
+	}
+	if n, err := strconv.ParseUint(strings.TrimSuffix(disk.Temperature, " °C"), 10, 64); err == nil && n >= temperatureLimit {
+		reasons = append(reasons, disk.Temperature)
+	}
+	if disk.UncorrectedErrors != "N/A" && disk.UncorrectedErrors != "0" {
+		reasons = append(reasons, disk.UncorrectedErrors+" uncorrected errors")
+	}
+
+	switch {
+	case len(reasons) > 0:
+		return strings.Join(reasons, "; ")
+	case disk.HealthStatus == "N/A" && disk.Wear == "N/A" && disk.UncorrectedErrors == "N/A":
+		return "N/A"
+	default:
+		return "None"
+	}
+}
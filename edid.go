@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EDID
+// The base block of a display's Extended Display Identification Data,
+// as far as an inventory cares, see VESA E-EDID 1.4.
+// Extension blocks, e.g., CTA-861, are ignored.
+type EDID struct {
+	ManufacturerID string // PNP ID, e.g., DEL
+	ProductCode    uint16
+	SerialNumber   uint32 // 0 if not set, see SerialString
+	SerialString   string // the serial number descriptor, if any
+	Name           string // the product name descriptor, if any
+	Week           int    // 1-54, 0 if unknown or a model year
+	Year           int
+	ModelYear      bool // Year is the model year, not the manufacture year
+
+	// Preferred timing, the native resolution
+	Width, Height int
+	// Image size in mm, 0 if unknown, e.g., projectors
+	WidthMM, HeightMM int
+}
+
+const edidBlockSize = 128
+
+var edidHeader = []byte{0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00}
+
+// Display descriptor tags
+const (
+	edidSerialTag = 0xFF
+	edidNameTag   = 0xFC
+)
+
+var (
+	ErrEDIDHeader   = errors.New("EDID: bad header")
+	ErrEDIDChecksum = errors.New("EDID: bad checksum")
+)
+
+// ParseEDID
+// Decode the base block of b, the whole blob as read from a display,
+// extensions included.
+func ParseEDID(b []byte) (EDID, error) {
+	var e EDID
+
+	if len(b) < edidBlockSize {
+		return e, fmt.Errorf("EDID: %d bytes, expecting at least %d", len(b), edidBlockSize)
+	}
+	b = b[:edidBlockSize]
+
+	if !bytes.Equal(b[:8], edidHeader) {
+		return e, ErrEDIDHeader
+	}
+	var sum byte
+	for _, c := range b {
+		sum += c
+	}
+	if sum != 0 {
+		return e, ErrEDIDChecksum
+	}
+
+	// Three 5-bit letters, 1 is A, big-endian unlike the rest.
+	id := binary.BigEndian.Uint16(b[8:])
+	e.ManufacturerID = string([]byte{
+		byte(id>>10&0x1F) + 'A' - 1,
+		byte(id>>5&0x1F) + 'A' - 1,
+		byte(id&0x1F) + 'A' - 1,
+	})
+	e.ProductCode = binary.LittleEndian.Uint16(b[10:])
+	e.SerialNumber = binary.LittleEndian.Uint32(b[12:])
+
+	e.Year = 1990 + int(b[17])
+	switch week := b[16]; {
+	case week == 0xFF:
+		e.ModelYear = true
+	case week <= 54:
+		e.Week = int(week)
+	}
+
+	// Maximum image size in cm, a fallback for the timing's mm.
+	e.WidthMM, e.HeightMM = int(b[21])*10, int(b[22])*10
+
+	preferred := true
+	for off := 54; off < 126; off += 18 {
+		d := b[off : off+18]
+
+		// A detailed timing, the first one is the preferred one.
+		if d[0] != 0 || d[1] != 0 {
+			if preferred {
+				e.parseTiming(d)
+				preferred = false
+			}
+			continue
+		}
+
+		switch d[3] {
+		case edidSerialTag:
+			e.SerialString = edidText(d[5:])
+		case edidNameTag:
+			e.Name = edidText(d[5:])
+		}
+	}
+
+	return e, nil
+}
+
+// parseTiming
+// Active pixels and image size of a detailed timing descriptor,
+// each as 8 low bits and 4 high bits elsewhere.
+func (e *EDID) parseTiming(d []byte) {
+	e.Width = int(d[2]) | int(d[4]>>4)<<8
+	e.Height = int(d[5]) | int(d[7]>>4)<<8
+
+	w := int(d[12]) | int(d[14]>>4)<<8
+	h := int(d[13]) | int(d[14]&0x0F)<<8
+	if w > 0 && h > 0 {
+		e.WidthMM, e.HeightMM = w, h
+	}
+}
+
+// edidText
+// A descriptor string, up to 13 characters, ended by a line feed
+// and padded with spaces.
+func edidText(b []byte) string {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(strings.ToValidUTF8(string(b), ""))
+}
+
+// Product
+// The product ID Windows names the display by, e.g., DELA0A5.
+func (e EDID) Product() string {
+	return fmt.Sprintf("%s%04X", e.ManufacturerID, e.ProductCode)
+}
+
+// Serial
+// The serial number descriptor, or else the numeric one, empty if neither.
+func (e EDID) Serial() string {
+	switch {
+	case e.SerialString != "":
+		return e.SerialString
+	case e.SerialNumber != 0:
+		return fmt.Sprint(e.SerialNumber)
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// The fixtures under testdata/edid are laid out as VESA E-EDID 1.4 says,
+// the way each kind of display fills it in:
+// a desktop monitor with a CTA-861 extension, serial and name descriptors,
+// a laptop panel with a model year and only unspecified text descriptors,
+// and a projector with no image size and only a numeric serial number.
+func readEDIDFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/edid/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseEDID(t *testing.T) {
+	for _, c := range []struct {
+		file string
+		want EDID
+	}{
+		{"dell-u2720q.bin", EDID{
+			ManufacturerID: "DEL", ProductCode: 0xA0A5,
+			SerialNumber: 0x4C463346, SerialString: "7GTVR23", Name: "DELL U2720Q",
+			Week: 14, Year: 2021,
+			Width: 3840, Height: 2160, WidthMM: 597, HeightMM: 336,
+		}},
+		{"boe-nv140fhm.bin", EDID{
+			ManufacturerID: "BOE", ProductCode: 0x0747,
+			Year: 2019, ModelYear: true, // week 0xFF
+			Width: 1920, Height: 1080, WidthMM: 309, HeightMM: 174, // the first timing, not the 40 Hz one
+		}},
+		{"sony-vpl-hw45.bin", EDID{
+			ManufacturerID: "SNY", ProductCode: 0x0101,
+			SerialNumber: 1234567, Name: "VPL-HW45",
+			Year:  2015, // week 0, unknown
+			Width: 1920, Height: 1080,
+		}},
+	} {
+		t.Run(c.file, func(t *testing.T) {
+			got, err := ParseEDID(readEDIDFixture(t, c.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("got  %+v\nwant %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseEDIDErrors(t *testing.T) {
+	dell := readEDIDFixture(t, "dell-u2720q.bin")
+
+	header := append([]byte(nil), dell...)
+	header[7] = 0xFF
+	checksum := append([]byte(nil), dell...)
+	checksum[0x7F]++
+	name := append([]byte(nil), dell...)
+	name[0x61] = 'X' // a letter of the name, so the checksum no longer adds up
+	extension := append([]byte(nil), dell...)
+	extension[0xFF]++ // the CTA block's own checksum isn't checked
+
+	for _, c := range []struct {
+		name string
+		b    []byte
+		want error
+	}{
+		{"header", header, ErrEDIDHeader},
+		{"checksum", checksum, ErrEDIDChecksum},
+		{"changed", name, ErrEDIDChecksum},
+		{"extension", extension, nil},
+	} {
+		if _, err := ParseEDID(c.b); !errors.Is(err, c.want) {
+			t.Errorf("%s: %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	if _, err := ParseEDID(dell[:100]); err == nil {
+		t.Error("truncated: no error")
+	}
+}
+
+// The image size in cm stands in for a timing without one.
+func TestParseEDIDSizeFallback(t *testing.T) {
+	b := append([]byte(nil), readEDIDFixture(t, "dell-u2720q.bin")[:edidBlockSize]...)
+	b[0x36+12], b[0x36+13], b[0x36+14] = 0, 0, 0
+	b[0x7F] = 0
+	var sum byte
+	for _, c := range b {
+		sum += c
+	}
+	b[0x7F] = -sum
+
+	e, err := ParseEDID(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.WidthMM != 600 || e.HeightMM != 340 {
+		t.Errorf("size = %d x %d mm, want 600 x 340 from 60 x 34 cm", e.WidthMM, e.HeightMM)
+	}
+}
+
+func TestMonitorFromEDID(t *testing.T) {
+	for file, want := range map[string]Monitor{
+		"dell-u2720q.bin": {
+			Manufacturer: "Dell", Model: "DELL U2720Q", ProductCode: "DELA0A5", SerialNumber: "7GTVR23",
+			WeekOfManufacture: "14", YearOfManufacture: "2021",
+			NativeResolution: "3840x2160", PhysicalSize: "597 x 336 mm", Diagonal: "27.0 in",
+		},
+		"boe-nv140fhm.bin": {
+			Manufacturer: "BOE", Model: "N/A", ProductCode: "BOE0747", SerialNumber: "N/A",
+			WeekOfManufacture: "N/A", YearOfManufacture: "2019",
+			NativeResolution: "1920x1080", PhysicalSize: "309 x 174 mm", Diagonal: "14.0 in",
+		},
+		"sony-vpl-hw45.bin": {
+			Manufacturer: "Sony", Model: "VPL-HW45", ProductCode: "SNY0101", SerialNumber: "1234567",
+			WeekOfManufacture: "N/A", YearOfManufacture: "2015",
+			NativeResolution: "1920x1080", PhysicalSize: "N/A", Diagonal: "N/A",
+		},
+	} {
+		e, err := ParseEDID(readEDIDFixture(t, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := monitorFromEDID(e); got != want {
+			t.Errorf("%s:\n got %+v\nwant %+v", file, got, want)
+		}
+	}
+}
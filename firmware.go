@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FirmwareSource
+// Returns the raw SMBIOS structure table, see ParseSMBIOS.
+type FirmwareSource interface {
+	SMBIOS() ([]byte, error)
+}
+
+// FirmwareFile
+// A FirmwareSource backed by a dumped table,
+// e.g., a copy of /sys/firmware/dmi/tables/DMI.
+type FirmwareFile struct {
+	Path string
+}
+
+// RecordingFirmware
+// Passes reads through to Firmware and dumps the table to Path.
+type RecordingFirmware struct {
+	Firmware FirmwareSource
+	Path     string
+}
+
+// Optional, every collector falls back without it.
+var _ = RegisterSection(Section{
+	Name: "SMBIOS", Key: "smbios", Order: 1,
+	Hidden: true,
+	New:    func() any { return &SMBIOS{} },
+	Collect: func(_ context.Context, src Sources, _ *Specs, node any) error {
+		t, err := src.readSMBIOS()
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil
+		case err != nil:
+			return warn(err)
+		}
+		*node.(*SMBIOS) = *t
+		return nil
+	},
+})
+
+// Linux keeps the table in sysfs, readable by root only.
+const sysfsSMBIOS = "sys/firmware/dmi/tables/DMI"
+
+func (f FirmwareFile) SMBIOS() ([]byte, error) {
+	return os.ReadFile(f.Path)
+}
+
+func (r RecordingFirmware) SMBIOS() ([]byte, error) {
+	data, err := r.Firmware.SMBIOS()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(r.Path, data, 0o644); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// readSMBIOS
+// Parse the table from whichever source has it.
+func (src Sources) readSMBIOS() (*SMBIOS, error) {
+	var data []byte
+	var err error
+
+	switch {
+	case src.Firmware != nil:
+		data, err = src.Firmware.SMBIOS()
+	case src.SysFS != nil:
+		data, err = fs.ReadFile(src.SysFS, sysfsSMBIOS)
+	default:
+		return nil, fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSMBIOS(data)
+}
+
+// smbios
+// The table read by the smbios section, nil if there's none,
+// for the sections depending on it.
+func (s *Specs) smbios() *SMBIOS {
+	if t, ok := s.node("smbios").(*SMBIOS); ok && len(t.Structures) > 0 {
+		return t
+	}
+	return nil
+}
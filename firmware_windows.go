@@ -0,0 +1,40 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// WinFirmware
+// The live FirmwareSource, backed by GetSystemFirmwareTable.
+type WinFirmware struct{}
+
+var procGetSystemFirmwareTable = windows.NewLazySystemDLL("kernel32.dll").
+	NewProc("GetSystemFirmwareTable")
+
+// 'RSMB', the raw SMBIOS table provider.
+const firmwareRSMB = 'R'<<24 | 'S'<<16 | 'M'<<8 | 'B'
+
+func (WinFirmware) SMBIOS() ([]byte, error) {
+	// The first call returns the size needed.
+	n, _, err := procGetSystemFirmwareTable.Call(firmwareRSMB, 0, 0, 0)
+	if n == 0 {
+		return nil, err
+	}
+
+	buf := make([]byte, n)
+	m, _, err := procGetSystemFirmwareTable.Call(
+		firmwareRSMB, 0, uintptr(unsafe.Pointer(&buf[0])), n)
+	if m == 0 {
+		return nil, err
+	}
+	if m > n {
+		return nil, errors.New("smbios: table grew between calls")
+	}
+
+	return rawSMBIOSTable(buf[:m])
+}
@@ -3,28 +3,39 @@
 package main
 
 import (
+	"context"
 	"golang.org/x/sys/windows"
 	"os"
+	"os/exec"
 	"syscall"
 )
 
 func init() {
-	var s Specs
-	if err := s.Collect(); err != nil {
+	conf, err := LoadLauncherConfig()
+	if err != nil {
 		errBox(err)
 		os.Exit(1)
 	}
-	if err := s.CollectProductKey(); err != nil {
+
+	// Every section, the product key included.
+	keys, _ := ParseSections("", "")
+	opts := CollectOptions{Sections: append(keys, "key")}
+
+	var s Specs
+	if err := s.Collect(context.Background(), DefaultSources(), opts); err != nil {
 		errBox(err)
 		os.Exit(1)
 	}
 
-	f, err := s.WriteHTML()
+	f, err := s.WriteHTML(conf.HTMLOptions())
 	if err != nil {
 		errBox(err)
 		os.Exit(1)
 	}
-	if err := s.OpenHTML(f); err != nil {
+	if !conf.OpenBrowser {
+		return
+	}
+	if err := OpenHTML(f); err != nil {
 		errBox(err)
 		os.Exit(1)
 	}
@@ -36,3 +47,17 @@ func errBox(err error) {
 
 	_, _ = windows.MessageBox(0, title, message, windows.MB_OK|windows.MB_ICONERROR)
 }
+
+// OpenHTML
+// Open the report with the default browser.
+func OpenHTML(filename string) error {
+	// Check if filename exists
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return err
+	}
+	cmd := exec.Command("rundll32", "url.dll,FileProtocolHandler", filename)
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+	return nil
+}
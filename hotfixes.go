@@ -0,0 +1,131 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Hotfixes
+// The patch level, i.e., the full build, updates included,
+// e.g., 26100.2605, and the updates installed, newest first.
+type Hotfixes struct {
+	Build         string
+	LastInstalled string // YYYY-MM-DD
+	Updates
+}
+
+type Updates []Update
+
+type Update struct {
+	HotFixID    string
+	Description string
+	InstalledOn string // YYYY-MM-DD, when Windows tells
+}
+
+// Part of Windows, but a long list, and a slow query on some machines.
+var _ = RegisterSection(Section{
+	Name: "Hotfixes", Key: "hotfixes",
+	Aliases: []string{"hotfix", "updates", "patches", "qfe"}, Order: 27,
+	Timeout: 30 * time.Second,
+	New:     func() any { return &Hotfixes{} },
+	Collect: func(ctx context.Context, src Sources, _ *Specs, node any) error {
+		return node.(*Hotfixes).collect(ctx, src)
+	},
+})
+
+// Only Windows has them, elsewhere the build is N/A and the list empty.
+func (h *Hotfixes) collect(ctx context.Context, src Sources) error {
+	h.Build, h.LastInstalled = "N/A", "N/A"
+	if src.WMI == nil {
+		return nil
+	}
+
+	// The build is there, even if the list is not.
+	buildErr := h.collectBuild(src.Registry)
+
+	var v []struct {
+		HotFixID    string
+		Description string
+		InstalledOn string
+	}
+
+	if err := query(ctx, src.WMI,
+		"SELECT HotFixID, Description, InstalledOn FROM Win32_QuickFixEngineering",
+		&v); err != nil {
+		return errors.Join(err, buildErr)
+	}
+
+	// The same update may be listed more than once, e.g., per component.
+	seen := map[string]bool{}
+	for _, u := range v {
+		if seen[u.HotFixID] {
+			continue
+		}
+		seen[u.HotFixID] = true
+
+		h.Updates = append(h.Updates, Update{
+			HotFixID:    cmp.Or(u.HotFixID, "N/A"),
+			Description: cmp.Or(u.Description, "N/A"),
+			InstalledOn: cmp.Or(hotfixDate(u.InstalledOn), "N/A"),
+		})
+	}
+
+	// N/A sorts last, as the oldest.
+	date := func(u Update) string {
+		if u.InstalledOn == "N/A" {
+			return ""
+		}
+		return u.InstalledOn
+	}
+	slices.SortStableFunc(h.Updates, func(a, b Update) int {
+		return cmp.Or(cmp.Compare(date(b), date(a)), cmp.Compare(a.HotFixID, b.HotFixID))
+	})
+	if len(h.Updates) > 0 {
+		h.LastInstalled = cmp.Or(date(h.Updates[0]), "N/A")
+	}
+
+	return buildErr
+}
+
+// hotfixDate
+// InstalledOn as YYYY-MM-DD. WMI writes it M/D/YYYY,
+// or else as a hex FILETIME on older systems, left as is.
+func hotfixDate(s string) string {
+	if t, err := time.Parse("1/2/2006", s); err == nil {
+		return t.Format(time.DateOnly)
+	}
+	return s
+}
+
+// collectBuild
+// CurrentBuild and UBR, the update build revision,
+// from the key Windows.collectVersion reads, too.
+func (h *Hotfixes) collectBuild(r RegistrySource) error {
+	reg, err := r.OpenKey(`HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion`)
+	if err != nil {
+		return warn(err)
+	}
+	defer func(reg RegistryKey) {
+		err := reg.Close()
+		if err != nil {
+			return
+		}
+	}(reg)
+
+	build, err := reg.GetStringValue("CurrentBuild")
+	if err != nil {
+		return warn(fmt.Errorf("CurrentBuild: %w", err))
+	}
+	ubr, err := reg.GetIntegerValue("UBR")
+	if err != nil {
+		h.Build = build
+		return warn(fmt.Errorf("UBR: %w", err))
+	}
+
+	h.Build = fmt.Sprintf("%s.%d", build, ubr)
+	return nil
+}
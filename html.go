@@ -1,4 +1,4 @@
-//go:build windows && gui
+//go:build cli || (windows && gui)
 
 package main
 
@@ -6,10 +6,13 @@ import (
 	"bytes"
 	_ "embed"
 	"encoding/base64"
+	"fmt"
 	"html/template"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"regexp"
+	"strings"
+	texttemplate "text/template"
 	"time"
 )
 
@@ -17,12 +20,14 @@ type tag struct {
 	h1
 	h2
 	h3
+	h4
 	section
 	table
 }
 type h1 struct{ begin, end string }
 type h2 struct{ begin, end string }
 type h3 struct{ begin, end string }
+type h4 struct{ begin, end string }
 type section struct{ begin, end string }
 type table struct {
 	begin, end string
@@ -31,7 +36,7 @@ type table struct {
 	td
 }
 type tbody struct{ begin, end string }
-type tr struct{ begin, end string }
+type tr struct{ begin, warning, end string }
 type td struct{ begin, end string }
 
 var t = tag{
@@ -47,8 +52,12 @@ var t = tag{
 		begin: `  <h3>`,
 		end:   `</h3>`,
 	},
+	h4: h4{
+		begin: `  <h4>`,
+		end:   `</h4>`,
+	},
 	section: section{
-		begin: `<section>`,
+		begin: `<section id="%s">`, // section name, see style.css
 		end:   `</section>`,
 	},
 	table: table{
@@ -59,8 +68,9 @@ var t = tag{
 			end:   `    </tbody>`,
 		},
 		tr: tr{
-			begin: `      <tr>`,
-			end:   `</tr>`,
+			begin:   `      <tr>`,
+			warning: `      <tr class="wsr-warning">`, // see style.css
+			end:     `</tr>`,
 		},
 		td: td{
 			begin: `<td>`,
@@ -85,12 +95,12 @@ func (s *Specs) genHTMLBody() (out string) {
 		switch {
 		case level[0].MatchString(col[0]):
 			if i == 0 {
-				out += t.section.begin + "\n"
+				out += fmt.Sprintf(t.section.begin, col[0]) + "\n"
 				out += t.h1.begin + col[0] + t.h1.end + "\n"
 			} else {
 				out += t.table.end + "\n"
 				out += t.section.end + "\n"
-				out += t.section.begin + "\n"
+				out += fmt.Sprintf(t.section.begin, col[0]) + "\n"
 				out += t.h1.begin + col[0] + t.h1.end + "\n"
 			}
 
@@ -98,11 +108,16 @@ func (s *Specs) genHTMLBody() (out string) {
 
 		default:
 			j := 1
-			if level[2].MatchString(col[0]) {
+			switch {
+			case level[2].MatchString(col[0]):
 				j = 2
+			case level[3].MatchString(col[0]):
+				j = 3
 			}
 
-			str := leads.ReplaceAllString(col[0], "")
+			// Values are as collected, e.g., program names, so anything goes.
+			str := template.HTMLEscapeString(leads.ReplaceAllString(col[0], ""))
+			val := template.HTMLEscapeString(col[1])
 
 			if col[1] == "" {
 				if !tableBeginAllowed {
@@ -115,6 +130,7 @@ func (s *Specs) genHTMLBody() (out string) {
 				out += map[int]string{
 					1: t.h2.begin + str + t.h2.end + "\n",
 					2: t.h3.begin + str + t.h3.end + "\n",
+					3: t.h4.begin + str + t.h4.end + "\n",
 				}[j]
 
 			} else {
@@ -125,9 +141,13 @@ func (s *Specs) genHTMLBody() (out string) {
 					tableBeginAllowed = false
 				}
 
-				out += t.table.tr.begin
+				if isHTMLWarning(str, col[1]) {
+					out += t.table.tr.warning
+				} else {
+					out += t.table.tr.begin
+				}
 				out += t.table.td.begin + str + t.table.td.end
-				out += t.table.td.begin + col[1] + t.table.td.end
+				out += t.table.td.begin + val + t.table.td.end
 				out += t.table.tr.end + "\n"
 			}
 		}
@@ -140,6 +160,12 @@ func (s *Specs) genHTMLBody() (out string) {
 	return out
 }
 
+// isHTMLWarning
+// Rows to stand out, i.e., a disk that may be failing.
+func isHTMLWarning(key, val string) bool {
+	return key == "SMARTWarning" && val != "None" && val != "N/A"
+}
+
 //go:embed assets/html.tmpl
 var htmlTmpl string
 
@@ -152,16 +178,16 @@ var htmlJS string
 //go:embed assets/favicon.ico
 var favicon []byte
 
-var htmlTimestamp = time.Now()
-
-func (s *Specs) genHTMLFull() (string, error) {
+// genHTMLFull
+// The printable report, as reported at the given time.
+func (s *Specs) genHTMLFull(at time.Time) (string, error) {
 	htmlData := map[string]any{
 		"body":      template.HTML(s.genHTMLBody()),
 		"css":       template.CSS(htmlCSS),
 		"js":        template.JS(htmlJS),
 		"icon":      base64.StdEncoding.EncodeToString(favicon),
 		"version":   Version,
-		"timestamp": htmlTimestamp.Format("Mon, 02 Jan 2006 15:04:05 UTC-0700"),
+		"timestamp": at.Format("Mon, 02 Jan 2006 15:04:05 UTC-0700"),
 	}
 
 	t := template.Must(template.New("htmlpage").Parse(htmlTmpl))
@@ -176,37 +202,108 @@ func (s *Specs) genHTMLFull() (string, error) {
 	return buff.String(), nil
 }
 
-func (s *Specs) WriteHTML() (filename string, err error) {
-	re := regexp.MustCompile(`([^\\]+)\\([^\\]+)`)
+// HTMLOptions
+// Where WriteHTML writes the report, and who gets to read it.
+type HTMLOptions struct {
+	Dir     string // created if missing, default current directory
+	Pattern string // file name, see Specs.ExpandName, default DefaultHTMLPattern
+
+	// Reports with the product key go here instead, if set,
+	// created if missing, owner only, whatever Shared says.
+	KeyDir string
 
-	userAtHost := re.ReplaceAllString(s.CurrentUser.Username, "$2@$1")
-	timestamp := htmlTimestamp.Format("20060102T150405-0700")
+	Mode   os.FileMode // default DefaultReportMode
+	Shared bool        // keep the ACL of the directory, instead of owner only
+}
 
-	filename = userAtHost + "_" + timestamp + ".html"
+const DefaultHTMLPattern = "{user}_{time}.html"
 
-	t, err := s.genHTMLFull()
+// WriteHTML
+// Write the printable report as a file, see HTMLOptions,
+// and return its path.
+func (s *Specs) WriteHTML(opts HTMLOptions) (filename string, err error) {
+	if opts.Pattern == "" {
+		opts.Pattern = DefaultHTMLPattern
+	}
+	if opts.Mode == 0 {
+		opts.Mode = DefaultReportMode
+	}
+
+	at := time.Now()
+	name, err := s.ExpandName(opts.Pattern, at)
 	if err != nil {
 		return "", err
 	}
 
-	// Write the HTML file.
-	// Permission 600 will make it readonly.
-	if err = os.WriteFile(filename, []byte(t), 700); err != nil {
+	dir := opts.Dir
+	if opts.KeyDir != "" && s.HasProductKey() {
+		dir, opts.Shared = opts.KeyDir, false
+
+		if err := makeOwnerDir(dir); err != nil {
+			return "", err
+		}
+	}
+	filename = filepath.Join(dir, name)
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return "", err
+	}
+
+	t, err := s.genHTMLFull(at)
+	if err != nil {
+		return "", err
+	}
+
+	if err = writeReportFile(filename, []byte(t), opts.Mode, opts.Shared); err != nil {
 		return "", err
 	}
 
 	return filename, nil
 }
 
-func (s *Specs) OpenHTML(filename string) error {
-	// Check if filename exists
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		return err
-	}
-	// Open the HTML file with the default browser.
-	cmd := exec.Command("rundll32", "url.dll,FileProtocolHandler", filename)
-	if err := cmd.Run(); err != nil {
-		return err
+// ExpandName
+// Fill in a file name pattern with the report's
+// {user}, as in user@DOMAIN, {host}, the device name, and {time}, e.g.,
+// "{user}_{time}.html" makes "jdoe@CORP_20250102T150405+0700.html",
+// or anything in it as a text/template of the sections by name,
+// along with .User, .Host and .Time, e.g., {{.Windows.CSName}}_{{.System.SKU}}.html.
+// Only what's filled in is made safe for a file name,
+// so the pattern itself can have directories.
+func (s *Specs) ExpandName(pattern string, at time.Time) (string, error) {
+	fields := s.templateData(at)
+
+	placeholders := strings.NewReplacer(
+		"{user}", fileNameSafe.Replace(fields["User"].(string)),
+		"{host}", fileNameSafe.Replace(fields["Host"].(string)),
+		"{time}", fields["Time"].(string),
+	)
+
+	var name strings.Builder
+	for _, part := range pathParts.FindAllString(pattern, -1) {
+		if !strings.Contains(part, "{{") {
+			name.WriteString(placeholders.Replace(part))
+			continue
+		}
+
+		t, err := texttemplate.New("name").Option("missingkey=error").Parse(part)
+		if err != nil {
+			return "", fmt.Errorf("file name %q: %w", pattern, err)
+		}
+		var buf strings.Builder
+		if err := t.Execute(&buf, fields); err != nil {
+			return "", fmt.Errorf("file name %q: %w", pattern, err)
+		}
+		name.WriteString(fileNameSafe.Replace(placeholders.Replace(buf.String())))
 	}
-	return nil
+
+	return name.String(), nil
 }
+
+// pathParts
+// Directories and separators of a path, as in ExpandName.
+var pathParts = regexp.MustCompile(`[/\\]|[^/\\]+`)
+
+// fileNameSafe
+// Replaces what Windows doesn't allow in file names.
+var fileNameSafe = strings.NewReplacer(
+	`/`, "_", `\`, "_", `:`, "_", `*`, "_", `?`, "_", `"`, "_", `<`, "_", `>`, "_", `|`, "_")
@@ -1,9 +1,7 @@
-//go:build windows
+//go:build !cli
 
 package main
 
-const Version = "0.1"
-
 func main() {
 	// Intentionally left empty.
 }
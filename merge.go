@@ -0,0 +1,289 @@
+//go:build cli
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Snapshot
+// A saved report, as collected on a single machine at Time,
+// or else the file's modification time, for reports that don't say.
+type Snapshot struct {
+	Path  string
+	Time  time.Time
+	Specs *Specs
+}
+
+// reportExts
+// Files picked up from directories, see LoadSnapshots.
+var reportExts = []string{".json", ".yaml", ".yml", ".toml"}
+
+// LoadSnapshots
+// Load every report in paths, files or directories of them,
+// the latter non-recursively.
+// Reports that can't be loaded are skipped, and returned as an error.
+func LoadSnapshots(paths []string) (snaps []Snapshot, err error) {
+	var errs []error
+
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		files := []string{p}
+		if fi.IsDir() {
+			files = nil
+			entries, err := os.ReadDir(p)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, e := range entries {
+				ext := strings.ToLower(filepath.Ext(e.Name()))
+				if e.Type().IsRegular() && slices.Contains(reportExts, ext) {
+					files = append(files, filepath.Join(p, e.Name()))
+				}
+			}
+		}
+
+		for _, f := range files {
+			snap, err := loadSnapshot(f)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			snaps = append(snaps, snap)
+		}
+	}
+
+	return snaps, errors.Join(errs...)
+}
+
+func loadSnapshot(path string) (Snapshot, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	var s Specs
+	if err := s.LoadFile(path); err != nil {
+		return Snapshot{}, err
+	}
+
+	at := s.collected
+	if at.IsZero() {
+		at = fi.ModTime()
+	}
+
+	return Snapshot{Path: path, Time: at, Specs: &s}, nil
+}
+
+// DeviceID
+// What tells machines apart: the system UUID, or the Windows serial number,
+// or the file itself when neither is known.
+func (snap Snapshot) DeviceID() string {
+	if sys, ok := snap.Specs.node("system").(*System); ok && known(sys.UUID) {
+		return "uuid:" + sys.UUID
+	}
+	if w, ok := snap.Specs.node("windows").(*Windows); ok && known(w.SerialNumber) {
+		return "serial:" + w.SerialNumber
+	}
+	return "file:" + snap.Path
+}
+
+// DeviceName
+// As reported by Windows, or the file name.
+func (snap Snapshot) DeviceName() string {
+	if w, ok := snap.Specs.node("windows").(*Windows); ok && known(w.CSName) {
+		return w.CSName
+	}
+	return strings.TrimSuffix(filepath.Base(snap.Path), filepath.Ext(snap.Path))
+}
+
+func known(v string) bool {
+	return v != "" && v != "N/A"
+}
+
+// Dedup
+// The newest snapshot of each device, see DeviceID, ordered by device name.
+func Dedup(snaps []Snapshot) []Snapshot {
+	newest := map[string]Snapshot{}
+	for _, snap := range snaps {
+		id := snap.DeviceID()
+		if v, ok := newest[id]; !ok || snap.Time.After(v.Time) {
+			newest[id] = snap
+		}
+	}
+
+	out := make([]Snapshot, 0, len(newest))
+	for _, v := range newest {
+		out = append(out, v)
+	}
+	slices.SortFunc(out, func(a, b Snapshot) int {
+		return cmp.Or(
+			strings.Compare(strings.ToLower(a.DeviceName()), strings.ToLower(b.DeviceName())),
+			strings.Compare(a.Path, b.Path),
+		)
+	})
+
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Merged outputs
+////////////////////////////////////////////////////////////////////////////////
+
+// MergeJSON
+// A JSON array of the reports.
+func MergeJSON(snaps []Snapshot) (string, error) {
+	docs := make([]string, len(snaps))
+	for i, snap := range snaps {
+		doc, err := snap.Specs.JSON()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", snap.Path, err)
+		}
+		docs[i] = doc
+	}
+
+	return "[" + strings.Join(docs, ",") + "]", nil
+}
+
+// MergeCSV
+// A row per report, or a row per component of each with CSVLong.
+// Columns have to line up, so the wide layout is taken as fixed.
+func MergeCSV(snaps []Snapshot, opts CSVOptions) (string, error) {
+	if opts.Layout != CSVLong {
+		opts.Layout = CSVFixed
+	}
+
+	var rows [][]string
+	for i, snap := range snaps {
+		var r [][]string
+
+		switch opts.Layout {
+		case CSVLong:
+			r = snap.Specs.csvLong()
+		default:
+			tbl := snap.Specs.csvTable(opts)
+			r = [][]string{make([]string, len(tbl)), make([]string, len(tbl))}
+			for j, col := range tbl {
+				r[0][j], r[1][j] = col[0], col[1]
+			}
+		}
+
+		// A single header
+		if i > 0 || opts.NoHeader {
+			r = r[1:]
+		}
+		rows = append(rows, r...)
+	}
+
+	return writeCSV(rows, opts)
+}
+
+// MergeHTML
+// An index page, a row per report, linking to each.
+func MergeHTML(snaps []Snapshot) (string, error) {
+	type row struct {
+		Snapshot
+		Name, User, System, CPU, Memory, Disks, ID string
+	}
+
+	rows := make([]row, len(snaps))
+	for i, snap := range snaps {
+		r := row{Snapshot: snap, Name: snap.DeviceName(), ID: snap.DeviceID()}
+		s := snap.Specs
+
+		if u, ok := s.node("user").(*CurrentUser); ok {
+			r.User = u.Username
+		}
+		if sys, ok := s.node("system").(*System); ok {
+			r.System = strings.TrimSpace(sys.Manufacturer + " " + sys.ProductName)
+		}
+		if c, ok := s.node("cpu").(*CPUs); ok && len(*c) > 0 {
+			r.CPU = (*c)[0].Name
+			if len(*c) > 1 {
+				r.CPU = fmt.Sprintf("%d × %s", len(*c), r.CPU)
+			}
+		}
+		if m, ok := s.node("memory").(*Memory); ok {
+			r.Memory = m.TotalSize.String()
+		}
+		if d, ok := s.node("disks").(*Disks); ok {
+			var size []string
+			for _, v := range *d {
+				size = append(size, v.Size.String())
+			}
+			r.Disks = strings.Join(size, ", ")
+		}
+
+		rows[i] = r
+	}
+
+	var buf bytes.Buffer
+	err := mergeHTMLTmpl.Execute(&buf, map[string]any{
+		"rows":      rows,
+		"units":     OutputUnits,
+		"version":   Version,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+	return buf.String(), err
+}
+
+var mergeHTMLTmpl = template.Must(template.New("index").Funcs(template.FuncMap{
+	"href": func(p string) string { return filepath.ToSlash(p) },
+	"time": func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+	<head>
+		<meta charset="UTF-8" />
+		<meta name="viewport" content="width=device-width, initial-scale=1" />
+		<title>Winspecter inventory</title>
+		<style>
+			body { font-family: sans-serif; margin: 2em; }
+			table { border-collapse: collapse; }
+			th, td { border: 1px solid #ccc; padding: .3em .6em; text-align: left; }
+			th { background: #eee; }
+			footer { margin-top: 1em; color: #666; font-size: smaller; }
+		</style>
+	</head>
+	<body>
+		<h1>Win Specs Reporter inventory</h1>
+		<table>
+			<thead>
+				<tr>
+					<th>Device</th><th>User</th><th>System</th><th>CPU</th>
+					<th>Memory{{if not .units}} (GiB){{end}}</th>
+					<th>Disks{{if not .units}} (GB){{end}}</th>
+					<th>ID</th><th>Snapshot</th>
+				</tr>
+			</thead>
+			<tbody>
+{{- range .rows}}
+				<tr>
+					<td><a href="{{href .Path}}">{{.Name}}</a></td>
+					<td>{{.User}}</td><td>{{.System}}</td><td>{{.CPU}}</td>
+					<td>{{.Memory}}</td><td>{{.Disks}}</td>
+					<td>{{.ID}}</td><td>{{time .Time}}</td>
+				</tr>
+{{- end}}
+			</tbody>
+		</table>
+		<footer>
+			<p>{{len .rows}} devices, merged by Winspecter v{{.version}} at {{.timestamp}}.</p>
+		</footer>
+	</body>
+</html>
+`))
@@ -0,0 +1,90 @@
+//go:build cli
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	src, err := ReplaySources("testdata/replay/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	save := func(name, host, uuid, serial string, collected, mtime time.Time) {
+		t.Helper()
+
+		var s Specs
+		if err := s.Collect(context.Background(), src, CollectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		s.collected = collected
+		s.node("system").(*System).UUID = uuid
+		w := s.node("windows").(*Windows)
+		w.CSName, w.SerialNumber = host, serial
+
+		doc, err := s.JSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jan := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	jun := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	const uuid1 = "4C4C4544-0035-4E10-804C-B7C04F593532"
+
+	// Copied over afterwards, so the older report has the newer mtime.
+	save("pc01-june.json", "AUDIT-PC01", uuid1, "00330-80000-00000-AA401", jun, jan)
+	save("pc01-january.json", "AUDIT-PC01", uuid1, "00330-80000-00000-AA401", jan, jun)
+	// No UUID, the Windows serial number it is.
+	save("pc02-june.json", "AUDIT-PC02", "N/A", "00330-80000-00000-AA402", jun, jun)
+	save("pc02-january.json", "AUDIT-PC02", "N/A", "00330-80000-00000-AA402", jan, jan)
+	// No collection time, the mtime it is.
+	save("pc03.json", "AUDIT-PC03", "03000200-0400-0500-0006-000700080009", "N/A", time.Time{}, jun)
+	// Neither, the file it is.
+	save("pc04.json", "AUDIT-PC04", "N/A", "N/A", jan, jan)
+
+	snaps, err := LoadSnapshots([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]Snapshot{}
+	for _, snap := range Dedup(snaps) {
+		got[snap.DeviceName()] = snap
+	}
+	if len(got) != 4 {
+		t.Fatalf("%d devices, want 4: %v", len(got), got)
+	}
+
+	for name, want := range map[string]struct {
+		file string
+		time time.Time
+	}{
+		"AUDIT-PC01": {"pc01-june.json", jun},
+		"AUDIT-PC02": {"pc02-june.json", jun},
+		"AUDIT-PC03": {"pc03.json", jun},
+		"AUDIT-PC04": {"pc04.json", jan},
+	} {
+		snap, ok := got[name]
+		switch {
+		case !ok:
+			t.Errorf("%s missing", name)
+		case filepath.Base(snap.Path) != want.file || !snap.Time.Equal(want.time):
+			t.Errorf("%s = %s at %v, want %s at %v", name, snap.Path, snap.Time, want.file, want.time)
+		}
+	}
+}
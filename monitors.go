@@ -0,0 +1,200 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"regexp"
+	"strings"
+)
+
+type Monitors []Monitor
+
+// Monitor
+// A connected display, as its EDID tells.
+type Monitor struct {
+	Manufacturer      string
+	Model             string // the name the display gives, e.g., DELL U2720Q
+	ProductCode       string // e.g., DELA0A5, as in Device Manager
+	SerialNumber      string
+	WeekOfManufacture string // 1-54, N/A when only the year is known
+	YearOfManufacture string // or the model year
+	NativeResolution  string // e.g., 3840x2160
+	PhysicalSize      string // e.g., 597 x 336 mm
+	Diagonal          string // e.g., 27.0 in
+}
+
+var _ = RegisterSection(Section{
+	Name: "Monitors", Key: "monitors",
+	Aliases: []string{"monitor", "displays", "edid"}, Order: 75,
+	New: func() any { return &Monitors{} },
+	Collect: func(ctx context.Context, src Sources, _ *Specs, node any) error {
+		return node.(*Monitors).collect(ctx, src)
+	},
+})
+
+// pnpVendors
+// The PNP IDs of the usual display makers, the rest are listed as is.
+var pnpVendors = map[string]string{
+	"ACI": "ASUS", "ACR": "Acer", "AOC": "AOC", "APP": "Apple", "AUO": "AU Optronics",
+	"AUS": "ASUS", "BNQ": "BenQ", "BOE": "BOE", "CMN": "Innolux", "DEL": "Dell",
+	"ENC": "EIZO", "GSM": "LG", "HPN": "HP", "HWP": "HP", "IVM": "Iiyama",
+	"LEN": "Lenovo", "LGD": "LG Display", "MSI": "MSI", "NEC": "NEC", "PHL": "Philips",
+	"SAM": "Samsung", "SDC": "Samsung Display", "SHP": "Sharp", "SNY": "Sony",
+	"VSC": "ViewSonic",
+}
+
+// WmiMonitorID only lists the displays connected, the registry every one ever.
+const monitorNamespace = `root\WMI`
+
+// wmiInstanceSuffix
+// The _0 WmiMonitorID appends to the device instance ID.
+var wmiInstanceSuffix = regexp.MustCompile(`_\d+$`)
+
+func (m *Monitors) collect(ctx context.Context, src Sources) error {
+	if src.SysFS != nil {
+		return m.collectSysFS(src.SysFS)
+	}
+
+	var v []struct {
+		InstanceName      string
+		WeekOfManufacture uint64
+		YearOfManufacture uint64
+	}
+
+	if err := query(ctx, src.WMI,
+		"SELECT InstanceName, WeekOfManufacture, YearOfManufacture FROM WmiMonitorID WHERE Active = TRUE",
+		&v, nil, monitorNamespace); err != nil {
+		return err
+	}
+
+	var warns []error
+	for _, w := range v {
+		// e.g., DISPLAY\DELA0A5\5&2f3a7c1&0&UID4353
+		instance := wmiInstanceSuffix.ReplaceAllString(w.InstanceName, "")
+
+		e, err := readEDID(src.Registry, instance)
+		if err != nil {
+			// The registry may lack it, e.g., over remote desktop, WMI still tells some.
+			warns = append(warns, warn(fmt.Errorf("%s: %w", instance, err)))
+			mon := Monitor{ProductCode: monitorProduct(instance)}
+			if len(mon.ProductCode) >= 3 {
+				id := mon.ProductCode[:3]
+				mon.Manufacturer = cmp.Or(pnpVendors[id], id)
+			}
+			mon.setManufactured(int(w.WeekOfManufacture), int(w.YearOfManufacture), w.WeekOfManufacture == 0xFF)
+			*m = append(*m, mon.orNA())
+			continue
+		}
+
+		*m = append(*m, monitorFromEDID(e))
+	}
+
+	return errors.Join(warns...)
+}
+
+// readEDID
+// The EDID Windows keeps of a display, by its device instance ID.
+func readEDID(r RegistrySource, instance string) (EDID, error) {
+	reg, err := r.OpenKey(`HKLM\SYSTEM\CurrentControlSet\Enum\` + instance + `\Device Parameters`)
+	if err != nil {
+		return EDID{}, err
+	}
+	defer func(reg RegistryKey) {
+		err := reg.Close()
+		if err != nil {
+			return
+		}
+	}(reg)
+
+	b, err := reg.GetBinaryValue("EDID")
+	if err != nil {
+		return EDID{}, fmt.Errorf("EDID: %w", err)
+	}
+	return ParseEDID(b)
+}
+
+// monitorProduct
+// The product ID of a device instance ID, e.g., DELA0A5.
+func monitorProduct(instance string) string {
+	parts := strings.Split(instance, `\`)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+func monitorFromEDID(e EDID) Monitor {
+	mon := Monitor{
+		Manufacturer: cmp.Or(pnpVendors[e.ManufacturerID], e.ManufacturerID),
+		Model:        e.Name,
+		ProductCode:  e.Product(),
+		SerialNumber: e.Serial(),
+	}
+	mon.setManufactured(e.Week, e.Year, e.ModelYear)
+
+	if e.Width > 0 && e.Height > 0 {
+		mon.NativeResolution = fmt.Sprintf("%dx%d", e.Width, e.Height)
+	}
+	if e.WidthMM > 0 && e.HeightMM > 0 {
+		mon.PhysicalSize = fmt.Sprintf("%d x %d mm", e.WidthMM, e.HeightMM)
+		mon.Diagonal = fmt.Sprintf("%.1f in", math.Hypot(float64(e.WidthMM), float64(e.HeightMM))/25.4)
+	}
+
+	return mon.orNA()
+}
+
+func (mon *Monitor) setManufactured(week, year int, modelYear bool) {
+	if week > 0 && !modelYear {
+		mon.WeekOfManufacture = fmt.Sprint(week)
+	}
+	if year > 0 {
+		mon.YearOfManufacture = fmt.Sprint(year)
+	}
+}
+
+// Handle empty string
+func (mon Monitor) orNA() Monitor {
+	for _, f := range []*string{&mon.Manufacturer, &mon.Model, &mon.ProductCode,
+		&mon.SerialNumber, &mon.WeekOfManufacture, &mon.YearOfManufacture,
+		&mon.NativeResolution, &mon.PhysicalSize, &mon.Diagonal} {
+		if *f == "" {
+			*f = "N/A"
+		}
+	}
+	return mon
+}
+
+// collectSysFS
+// Every connected DRM connector has its display's EDID, e.g., card0-HDMI-A-1/edid.
+func (m *Monitors) collectSysFS(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, "sys/class/drm")
+	if err != nil {
+		return nil // no DRM, no monitor
+	}
+
+	var warns []error
+	for _, e := range entries {
+		dir := "sys/class/drm/" + e.Name()
+		if readSysFS(fsys, dir+"/status") != "connected" {
+			continue
+		}
+
+		b, err := fs.ReadFile(fsys, dir+"/edid")
+		if err != nil || len(b) == 0 {
+			continue
+		}
+		edid, err := ParseEDID(b)
+		if err != nil {
+			warns = append(warns, warn(fmt.Errorf("%s: %w", e.Name(), err)))
+			continue
+		}
+
+		*m = append(*m, monitorFromEDID(edid))
+	}
+
+	return errors.Join(warns...)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DefaultReportMode
+// Reports may hold the product key, so only the owner reads them by default.
+const DefaultReportMode os.FileMode = 0600
+
+// ParseFileMode
+// An octal mode, e.g., "0640", as in winspecter.toml and -mode.
+func ParseFileMode(s string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m&^0777 != 0 {
+		return 0, fmt.Errorf("invalid file mode %q, expecting octal, e.g., '0600'", s)
+	}
+	return os.FileMode(m), nil
+}
+
+// makeOwnerDir
+// Create dir if missing, and make it the current user's only,
+// e.g., for reports with the product key.
+func makeOwnerDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return restrictToOwner(dir, 0700)
+}
+
+// writeReportFile
+// Write data to path as mode, restricted to the current user unless shared,
+// before anything is written to it.
+// On Windows, mode only tells apart read-only files, it's the ACL that restricts.
+//
+// It's written to a temporary file next to path, then renamed over it,
+// so a read-only report from an earlier run is replaced, not reopened,
+// and a failed write leaves it as it was.
+func writeReportFile(path string, data []byte, mode os.FileMode, shared bool) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	// Whatever mode says for group and others.
+	if !shared {
+		mode &= 0700
+	}
+
+	// CreateTemp makes it the owner's only, whatever mode says.
+	if err := os.Chmod(tmp, mode|0200); err != nil {
+		return err
+	}
+	if !shared {
+		if err := restrictToOwner(tmp, mode|0200); err != nil {
+			return err
+		}
+	}
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// Read-only, if so asked, only once written.
+	if err := os.Chmod(tmp, mode); err != nil {
+		return err
+	}
+	return replaceFile(tmp, path)
+}
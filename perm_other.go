@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// restrictToOwner
+// Drop group and other permissions.
+func restrictToOwner(path string, mode os.FileMode) error {
+	return os.Chmod(path, mode&0700)
+}
+
+// replaceFile
+// Rename from over to, whatever its mode.
+func replaceFile(from, to string) error {
+	return os.Rename(from, to)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// A read-only report is replaced by the next run, not reopened.
+func TestWriteReportFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pc01.html")
+
+	for _, c := range []struct {
+		data   string
+		mode   os.FileMode
+		shared bool
+	}{
+		{"first run", 0400, false},
+		{"second run", 0400, false},
+		{"shared", 0644, true},
+		{"private, group and others asked for", 0644, false},
+		{"private again", DefaultReportMode, false},
+	} {
+		if err := writeReportFile(path, []byte(c.data), c.mode, c.shared); err != nil {
+			t.Fatalf("%s: %v", c.data, err)
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil || string(b) != c.data {
+			t.Errorf("%s: read %q, %v", c.data, b, err)
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := c.mode
+		if !c.shared {
+			want &= 0700
+		}
+		if runtime.GOOS == "windows" { // only read-only tells
+			want = 0666
+			if c.mode&0200 == 0 {
+				want = 0444
+			}
+		}
+		if got := fi.Mode().Perm(); got != want {
+			t.Errorf("%s: mode %v, want %v", c.data, got, want)
+		}
+	}
+
+	// No temporary file left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in %s, want only the report", len(entries), dir)
+	}
+}
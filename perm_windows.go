@@ -0,0 +1,49 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// restrictToOwner
+// Replace the file's ACL, inherited from its directory,
+// with full access for the current user only.
+func restrictToOwner(path string, _ os.FileMode) error {
+	u, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return err
+	}
+
+	acl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{{
+		AccessPermissions: windows.GENERIC_ALL,
+		AccessMode:        windows.GRANT_ACCESS,
+		Inheritance:       windows.NO_INHERITANCE,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  windows.TRUSTEE_IS_USER,
+			TrusteeValue: windows.TrusteeValueFromSID(u.User.Sid),
+		},
+	}}, nil)
+	if err != nil {
+		return err
+	}
+
+	return windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION,
+		nil, nil, acl, nil)
+}
+
+// replaceFile
+// Rename from over to, which has to be writable to be replaced,
+// e.g., a read-only report of an earlier run.
+func replaceFile(from, to string) error {
+	if fi, err := os.Lstat(to); err == nil && fi.Mode().IsRegular() && fi.Mode().Perm()&0200 == 0 {
+		if err := os.Chmod(to, fi.Mode().Perm()|0200); err != nil {
+			return err
+		}
+	}
+	return os.Rename(from, to)
+}
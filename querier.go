@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"reflect"
+)
+
+// Querier
+// Runs a WQL query and loads the result set into dst,
+// a pointer to a slice of structs, the same way wmi.Query does.
+// Collectors only talk to WMI through this interface,
+// so the live backend can be swapped for recorded fixtures.
+type Querier interface {
+	Query(query string, dst any, connectServerArgs ...any) error
+}
+
+// query
+// Run the query in the background and give up once ctx is done.
+// WMI calls can't be interrupted, so an abandoned query keeps running
+// until WMI returns, but into its own copy of dst, never into dst itself,
+// and its goroutine exits as soon as it does.
+func query(ctx context.Context, q Querier, wql string, dst any, connectServerArgs ...any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	res := reflect.New(reflect.TypeOf(dst).Elem())
+
+	done := make(chan error, 1) // buffered, never blocks an abandoned query
+	go func() {
+		done <- q.Query(wql, res.Interface(), connectServerArgs...)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		reflect.ValueOf(dst).Elem().Set(res.Elem())
+		return nil
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// Fixture
+// A raw WMI result set as stored on disk.
+// Rows hold the plain property values, before any of our String()
+// or Marshal*() formatting, so a replay loads exactly what WMI returned.
+type Fixture struct {
+	Query string
+	Rows  []map[string]any
+}
+
+// RecordingQuerier
+// Passes queries through to Querier and dumps every result set
+// to Dir as <WMI class>.json.
+type RecordingQuerier struct {
+	Querier Querier
+	Dir     string
+}
+
+// ReplayQuerier
+// Answers queries from the fixtures written by RecordingQuerier,
+// as long as the query is the recorded one.
+type ReplayQuerier struct {
+	Dir string
+}
+
+var wqlClass = regexp.MustCompile(`(?i)\bFROM\s+(\w+)`)
+
+////////////////////////////////////////////////////////////////////////////////
+// Public Methods
+////////////////////////////////////////////////////////////////////////////////
+
+func (r RecordingQuerier) Query(query string, dst any, connectServerArgs ...any) error {
+	if err := r.Querier.Query(query, dst, connectServerArgs...); err != nil {
+		return err
+	}
+
+	rows, err := rawRows(dst)
+	if err != nil {
+		return err
+	}
+
+	file, err := fixturePath(r.Dir, query)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(Fixture{Query: query, Rows: rows}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+func (r ReplayQuerier) Query(query string, dst any, _ ...any) error {
+	file, err := fixturePath(r.Dir, query)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	// UseNumber keeps 64-bit sizes exact.
+	var f Fixture
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&f); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	// Other properties or conditions would replay the wrong rows.
+	if f.Query != query {
+		return fmt.Errorf("%s: recorded for %q, not %q", file, f.Query, query)
+	}
+
+	return fillRows(f.Rows, dst)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////////////
+
+// fixturePath
+// Fixtures are keyed by the queried WMI class,
+// a query per class, checked by ReplayQuerier.
+func fixturePath(dir, query string) (string, error) {
+	m := wqlClass.FindStringSubmatch(query)
+	if m == nil {
+		return "", fmt.Errorf("no WMI class in query: %s", query)
+	}
+	return filepath.Join(dir, m[1]+".json"), nil
+}
+
+// rawRows
+// Flatten a wmi.Query destination into plain values,
+// i.e., strings, integers, floats, bools and slices of those.
+func rawRows(dst any) ([]map[string]any, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("wmi destination must be a pointer to slice, got %T", dst)
+	}
+	v = v.Elem()
+
+	rows := make([]map[string]any, 0, v.Len())
+	for i := range v.Len() {
+		e := reflect.Indirect(v.Index(i))
+		t := e.Type()
+
+		row := map[string]any{}
+		for j := range t.NumField() {
+			if !t.Field(j).IsExported() {
+				continue
+			}
+			row[t.Field(j).Name] = rawValue(e.Field(j))
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func rawValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		s := make([]any, v.Len())
+		for i := range v.Len() {
+			s[i] = rawValue(v.Index(i))
+		}
+		return s
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return rawValue(v.Elem())
+	default:
+		return nil
+	}
+}
+
+// fillRows
+// The reverse of rawRows, loosely following wmi.Query's conversions:
+// properties missing from a row are left zeroed.
+func fillRows(rows []map[string]any, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("wmi destination must be a pointer to slice, got %T", dst)
+	}
+	v = v.Elem()
+
+	s := reflect.MakeSlice(v.Type(), len(rows), len(rows))
+	for i, row := range rows {
+		e := s.Index(i)
+		if e.Kind() == reflect.Ptr {
+			e.Set(reflect.New(e.Type().Elem()))
+			e = e.Elem()
+		}
+		t := e.Type()
+
+		for j := range t.NumField() {
+			name := t.Field(j).Name
+			raw, ok := row[name]
+			if !ok || !t.Field(j).IsExported() {
+				continue
+			}
+			if err := setRaw(e.Field(j), raw); err != nil {
+				return fmt.Errorf("%s.%s: %w", t.Name(), name, err)
+			}
+		}
+	}
+	v.Set(s)
+
+	return nil
+}
+
+func setRaw(f reflect.Value, raw any) error {
+	if raw == nil {
+		return nil
+	}
+
+	if f.Kind() == reflect.Ptr {
+		f.Set(reflect.New(f.Type().Elem()))
+		f = f.Elem()
+	}
+
+	var s string
+	switch r := raw.(type) {
+	case json.Number:
+		s = r.String()
+	case string:
+		s = r
+	case bool:
+		if f.Kind() != reflect.Bool {
+			return fmt.Errorf("cannot load bool into %s", f.Type())
+		}
+		f.SetBool(r)
+		return nil
+	case []any:
+		if f.Kind() != reflect.Slice {
+			return fmt.Errorf("cannot load array into %s", f.Type())
+		}
+		sl := reflect.MakeSlice(f.Type(), len(r), len(r))
+		for i := range r {
+			if err := setRaw(sl.Index(i), r[i]); err != nil {
+				return err
+			}
+		}
+		f.Set(sl)
+		return nil
+	default:
+		return fmt.Errorf("unsupported value %T", raw)
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("cannot load %q into %s", s, f.Type())
+	}
+
+	return nil
+}
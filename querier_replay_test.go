@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The whole Windows pipeline, off recorded WMI results and registry values.
+func TestReplayCollect(t *testing.T) {
+	src, err := ReplaySources("testdata/replay/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s Specs
+	if err := s.Collect(context.Background(), src, CollectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A warning for each of what the fixtures leave out, the rest is there.
+	var warnings []string
+	for _, d := range s.Diagnostics {
+		if d.Severity != SeverityWarning {
+			t.Errorf("%s: %s", d.Severity, d.Message)
+		}
+		what, _, _ := strings.Cut(d.Message, ":")
+		warnings = append(warnings, what)
+	}
+	slices.Sort(warnings)
+	if want := []string{"SystemSKU"}; !slices.Equal(warnings, want) {
+		t.Fatalf("Diagnostics = %+v, want warnings for %q", s.Diagnostics, want)
+	}
+
+	t.Run("N/A", func(t *testing.T) {
+		bbs := s.node("bbs").(*BBS)
+		gpus := *s.node("gpu").(*GPUs)
+		dimms := s.node("memory").(*Memory).DIMMs
+		disks := *s.node("disks").(*Disks)
+		updates := s.node("hotfixes").(*Hotfixes).Updates
+
+		for _, c := range []struct {
+			name, got string
+		}{
+			{"System.SKU", bbs.System.SKU},
+			{"GPU1.AdapterCompatibility", gpus[1].AdapterCompatibility},
+			{"GPU1.AdapterDACType", gpus[1].AdapterDACType},
+			{"DIMM1.Manufacturer", dimms[1].Manufacturer},
+			{"DIMM1.PartNumber", dimms[1].PartNumber}, // blank-padded
+			{"DIMM1.SerialNumber", dimms[1].SerialNumber},
+			{"Disk1.Model", disks[1].Model},
+			{"Disk1.SerialNumber", disks[1].SerialNumber},
+			{"Disk1.FirmwareVersion", disks[1].FirmwareVersion},
+			{"Disk1.PowerOnHours", disks[1].PowerOnHours}, // null
+			{"Disk1.Volume0.Label", disks[1].Volumes[0].Label},
+			{"Update2.Description", updates[2].Description},
+			{"Update2.InstalledOn", updates[2].InstalledOn},
+		} {
+			if c.got != "N/A" {
+				t.Errorf("%s = %q, want N/A", c.name, c.got)
+			}
+		}
+
+		if got := dimms[0].PartNumber; got != "K4U6E3S4AA-MGCR" {
+			t.Errorf("DIMM0.PartNumber = %q, want it trimmed", got)
+		}
+	})
+
+	t.Run("virtual adapters", func(t *testing.T) {
+		var names []string
+		for _, a := range *s.node("network").(*NetAdapters) {
+			names = append(names, a.Name)
+		}
+
+		// TAP-Windows, WireGuard, VirtualBox and Fortinet are dropped.
+		want := []string{"Intel(R) Wi-Fi 6 AX201 160MHz", "Realtek USB GbE Family Controller"}
+		if !slices.Equal(names, want) {
+			t.Errorf("NetAdapters = %q, want %q", names, want)
+		}
+	})
+
+	t.Run("monitors", func(t *testing.T) {
+		// Decoded from the EDID of the active WmiMonitorID instance, in the registry.
+		want := Monitors{{
+			Manufacturer: "Dell", Model: "DELL U2720Q", ProductCode: "DELA0A5", SerialNumber: "7GTVR23",
+			WeekOfManufacture: "14", YearOfManufacture: "2021",
+			NativeResolution: "3840x2160", PhysicalSize: "597 x 336 mm", Diagonal: "27.0 in",
+		}}
+		if got := *s.node("monitors").(*Monitors); !slices.Equal(got, want) {
+			t.Errorf("Monitors = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("disk health", func(t *testing.T) {
+		var got []string
+		for _, d := range *s.node("disks").(*Disks) {
+			got = append(got, fmt.Sprintf("%s %s %s %s", d.MediaType, d.BusType, d.HealthStatus, d.SMARTWarning))
+		}
+		if want := []string{"SSD NVMe Healthy None", "HDD USB Healthy None"}; !slices.Equal(got, want) {
+			t.Errorf("disks = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("volumes", func(t *testing.T) {
+		var got []string
+		for _, d := range *s.node("disks").(*Disks) {
+			for _, v := range d.Volumes {
+				got = append(got, fmt.Sprintf("%s %s %s %s", d.PartitionStyle, v.DriveLetter, v.FileSystem, v.BitLocker))
+			}
+		}
+		if want := []string{"GPT C: NTFS On", "MBR D: exFAT Off"}; !slices.Equal(got, want) {
+			t.Errorf("volumes = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("hotfixes", func(t *testing.T) {
+		h := s.node("hotfixes").(*Hotfixes)
+		if h.Build != "22631.3370" || h.LastInstalled != "2023-11-20" {
+			t.Errorf("Build = %q, LastInstalled = %q, want 22631.3370 and 2023-11-20", h.Build, h.LastInstalled)
+		}
+
+		// Newest first, the one without a date last.
+		var ids []string
+		for _, u := range h.Updates {
+			ids = append(ids, u.HotFixID)
+		}
+		if want := []string{"KB5032007", "KB5031356", "KB4999999"}; !slices.Equal(ids, want) {
+			t.Errorf("Updates = %q, want %q", ids, want)
+		}
+	})
+
+	t.Run("programs", func(t *testing.T) {
+		// By name, the installer's YYYYMMDD as a date, N/A for 7-Zip.
+		want := InstalledSoftware{
+			{Name: "7-Zip 23.01 (x64)", Version: "23.01", Publisher: "Igor Pavlov", InstallDate: "N/A", Size: 5741},
+			{Name: "Git", Version: "2.44.0", Publisher: "The Git Development Community", InstallDate: "2024-03-15", Size: 303792},
+		}
+		if got := *s.node("software").(*InstalledSoftware); !slices.Equal(got, want) {
+			t.Errorf("InstalledSoftware = %+v, want %+v", got, want)
+		}
+	})
+}
+
+// A fixture only answers the query it was recorded for.
+func TestReplayQueryMismatch(t *testing.T) {
+	q := ReplayQuerier{Dir: "testdata/replay/windows"}
+
+	var dst []struct{ Name string }
+	for _, wql := range []string{
+		"SELECT Name FROM Win32_Processor",
+		"SELECT Name, AdapterCompatibility, AdapterDACType FROM Win32_VideoController WHERE Name = 'x'",
+	} {
+		if err := q.Query(wql, &dst); err == nil || !strings.Contains(err.Error(), "recorded for") {
+			t.Errorf("%s: %v, want a mismatch", wql, err)
+		}
+	}
+
+	if err := q.Query("SELECT Name, AdapterCompatibility, AdapterDACType FROM Win32_VideoController", &dst); err != nil || len(dst) != 2 {
+		t.Errorf("recorded query = %d rows, %v", len(dst), err)
+	}
+}
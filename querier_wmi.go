@@ -0,0 +1,15 @@
+//go:build windows
+
+package main
+
+import (
+	"github.com/yusufpapurcu/wmi"
+)
+
+// WMI
+// The live Querier, backed by the local WMI service.
+type WMI struct{}
+
+func (WMI) Query(query string, dst any, connectServerArgs ...any) error {
+	return wmi.Query(query, dst, connectServerArgs...)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RegistrySource
+// Opens registry keys by their full path, hive included,
+// e.g., HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion.
+// Collectors only read the registry through this interface,
+// so the live registry can be swapped for a .reg export.
+type RegistrySource interface {
+	OpenKey(path string) (RegistryKey, error)
+}
+
+// RegistryKey
+// An open key, only value reads and subkey names are needed.
+type RegistryKey interface {
+	GetStringValue(name string) (string, error)
+	GetIntegerValue(name string) (uint64, error)
+	GetBinaryValue(name string) ([]byte, error)
+	ReadSubKeyNames() ([]string, error)
+	Close() error
+}
+
+// Hive abbreviations, as accepted by reg.exe.
+var regHives = map[string]string{
+	"HKLM": "HKEY_LOCAL_MACHINE",
+	"HKCU": "HKEY_CURRENT_USER",
+	"HKCR": "HKEY_CLASSES_ROOT",
+	"HKU":  "HKEY_USERS",
+	"HKCC": "HKEY_CURRENT_CONFIG",
+}
+
+// splitRegPath
+// Split a full key path into its long hive name and the subkey path.
+func splitRegPath(path string) (hive, subkey string, err error) {
+	hive, subkey, _ = strings.Cut(strings.Trim(path, `\`), `\`)
+	hive = strings.ToUpper(hive)
+
+	if long, ok := regHives[hive]; ok {
+		hive = long
+	}
+	for _, long := range regHives {
+		if hive == long {
+			return hive, subkey, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unknown registry hive in %s", path)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Windows info
+////////////////////////////////////////////////////////////////////////////////
+
+func (w *Windows) collectVersion(r RegistrySource) error {
+	reg, err := r.OpenKey(`HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion`)
+	if err != nil {
+		w.Version = "N/A"
+		return warn(err)
+	}
+	defer func(reg RegistryKey) {
+		err := reg.Close()
+		if err != nil {
+			return
+		}
+	}(reg)
+
+	// DisplayVersion came with 20H2, ReleaseId is older.
+	w.Version, err = reg.GetStringValue("DisplayVersion")
+	if err != nil {
+		w.Version, err = reg.GetStringValue("ReleaseId")
+	}
+	if err != nil {
+		w.Version = "N/A"
+		return warn(fmt.Errorf("DisplayVersion: %w", err))
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// BIOS, Baseboard, System (BBS)
+////////////////////////////////////////////////////////////////////////////////
+
+func (b *BBS) collect(src Sources) error {
+	switch {
+	case src.smbios != nil:
+		b.collectSMBIOS(src.smbios)
+		return nil
+	case src.SysFS != nil:
+		return b.collectSysFS(src.SysFS)
+	}
+
+	// Only in the SMBIOS table
+	b.System.UUID = "N/A"
+
+	reg, err := src.Registry.OpenKey(`HKLM\HARDWARE\Description\System\BIOS`)
+	if err != nil {
+		return err
+	}
+	defer func(reg RegistryKey) {
+		err := reg.Close()
+		if err != nil {
+			return
+		}
+	}(reg)
+
+	// Old firmware may lack some of these, e.g., SystemSKU.
+	// Those are N/A with a warning, the rest is still usable.
+	fields := []struct {
+		dst  *string
+		name string
+	}{
+		{&b.BIOS.Vendor, "BIOSVendor"},
+		{&b.BIOS.Version, "BIOSVersion"},
+		{&b.BIOS.ReleaseDate, "BIOSReleaseDate"},
+		{&b.Baseboard.Manufacturer, "BaseBoardManufacturer"},
+		{&b.Baseboard.Product, "BaseBoardProduct"},
+		{&b.Baseboard.Version, "BaseBoardVersion"},
+		{&b.System.Manufacturer, "SystemManufacturer"},
+		{&b.System.Family, "SystemFamily"},
+		{&b.System.Version, "SystemVersion"},
+		{&b.System.ProductName, "SystemProductName"},
+		{&b.System.SKU, "SystemSKU"},
+	}
+
+	var warns []error
+	for _, f := range fields {
+		*f.dst, err = reg.GetStringValue(f.name)
+		if err != nil {
+			warns = append(warns, warn(fmt.Errorf("%s: %w", f.name, err)))
+		}
+		if *f.dst == "" {
+			*f.dst = "N/A"
+		}
+	}
+
+	return errors.Join(warns...)
+}
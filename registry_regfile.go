@@ -0,0 +1,550 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// RegFile
+// An in-memory RegistrySource, loaded from a regedit export (.reg)
+// or filled by RecordingRegistry.
+type RegFile struct {
+	mu   sync.Mutex
+	keys map[string]*regFileKey // by lower-cased full path
+}
+
+// RecordingRegistry
+// Passes reads through to Registry and keeps every value read in File,
+// to be saved as a .reg fixture.
+type RecordingRegistry struct {
+	Registry RegistrySource
+	File     *RegFile
+}
+
+type regFileKey struct {
+	path   string               // long hive name, as written to files
+	values map[string]*regValue // by lower-cased value name
+}
+
+type regValue struct {
+	name string
+	kind int
+	str  string
+	num  uint64
+	bin  []byte
+}
+
+// Value types, numbered as in winnt.h.
+const (
+	regSZ       = 1
+	regExpandSZ = 2
+	regBinary   = 3
+	regDWORD    = 4
+	regMultiSZ  = 7
+	regQWORD    = 11
+)
+
+var ErrRegNotExist = errors.New("the system cannot find the file specified")
+
+////////////////////////////////////////////////////////////////////////////////
+// Public Methods
+////////////////////////////////////////////////////////////////////////////////
+
+func NewRegFile() *RegFile {
+	return &RegFile{keys: map[string]*regFileKey{}}
+}
+
+func LoadRegFile(name string) (*RegFile, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := ParseRegFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return f, nil
+}
+
+// ParseRegFile
+// Accepts both "REGEDIT4" and "Windows Registry Editor Version 5.00" exports,
+// in UTF-16 (as written by regedit) or UTF-8.
+func ParseRegFile(data []byte) (*RegFile, error) {
+	f := NewRegFile()
+
+	var key *regFileKey
+	var line string
+
+	sc := bufio.NewScanner(strings.NewReader(decodeRegText(data)))
+	sc.Buffer(nil, 1<<24)
+
+	for n := 1; sc.Scan(); n++ {
+		// Hex data may continue on the next line after a trailing backslash.
+		line += strings.TrimSpace(sc.Text())
+		if strings.HasSuffix(line, `\`) && !strings.HasPrefix(line, `[`) {
+			line = strings.TrimSuffix(line, `\`)
+			continue
+		}
+
+		switch {
+		case line == "", strings.HasPrefix(line, ";"),
+			line == "REGEDIT4", strings.HasPrefix(line, "Windows Registry Editor"):
+			// Skip
+
+		case strings.HasPrefix(line, "[-"):
+			key = nil // deleted key
+
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			path := line[1 : len(line)-1]
+			hive, subkey, err := splitRegPath(path)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			key = f.key(hive + `\` + subkey)
+
+		case key == nil:
+			// Values of a deleted or missing key
+
+		default:
+			v, err := parseRegValue(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			if v != nil {
+				key.values[strings.ToLower(v.name)] = v
+			}
+		}
+
+		line = ""
+	}
+
+	return f, sc.Err()
+}
+
+func (f *RegFile) OpenKey(path string) (RegistryKey, error) {
+	hive, subkey, err := splitRegPath(path)
+	if err != nil {
+		return nil, err
+	}
+	path = hive + `\` + subkey
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.exists(path) {
+		return nil, fmt.Errorf("%s: %w", path, ErrRegNotExist)
+	}
+	return &regFileHandle{file: f, path: path}, nil
+}
+
+// WriteTo
+// Write in the regedit 5.00 format, keys sorted by path.
+func (f *RegFile) WriteTo(w io.Writer) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var b bytes.Buffer
+	b.WriteString("Windows Registry Editor Version 5.00\r\n")
+
+	paths := make([]string, 0, len(f.keys))
+	for p := range f.keys {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	for _, p := range paths {
+		k := f.keys[p]
+		b.WriteString("\r\n[" + k.path + "]\r\n")
+
+		names := make([]string, 0, len(k.values))
+		for n := range k.values {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+
+		for _, n := range names {
+			b.WriteString(k.values[n].String() + "\r\n")
+		}
+	}
+
+	return b.WriteTo(w)
+}
+
+// Save
+// Write the file to name.
+func (f *RegFile) Save(name string) error {
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteTo(out); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func (r RecordingRegistry) OpenKey(path string) (RegistryKey, error) {
+	key, err := r.Registry.OpenKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hive, subkey, err := splitRegPath(path)
+	if err != nil {
+		return nil, err
+	}
+	path = hive + `\` + subkey
+
+	r.File.mu.Lock()
+	r.File.key(path)
+	r.File.mu.Unlock()
+
+	return &recordingKey{RegistryKey: key, file: r.File, path: path}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Keys
+////////////////////////////////////////////////////////////////////////////////
+
+type regFileHandle struct {
+	file *RegFile
+	path string
+}
+
+func (h *regFileHandle) value(name string) (*regValue, error) {
+	h.file.mu.Lock()
+	defer h.file.mu.Unlock()
+
+	// An implied key has no entry, nor values.
+	var v *regValue
+	if k := h.file.keys[strings.ToLower(h.path)]; k != nil {
+		v = k.values[strings.ToLower(name)]
+	}
+	if v == nil {
+		return nil, fmt.Errorf(`%s\%s: %w`, h.path, name, ErrRegNotExist)
+	}
+	return v, nil
+}
+
+func (h *regFileHandle) GetStringValue(name string) (string, error) {
+	v, err := h.value(name)
+	if err != nil {
+		return "", err
+	}
+	if v.kind != regSZ && v.kind != regExpandSZ {
+		return "", fmt.Errorf(`%s\%s: not a string value`, h.path, name)
+	}
+	return v.str, nil
+}
+
+func (h *regFileHandle) GetIntegerValue(name string) (uint64, error) {
+	v, err := h.value(name)
+	if err != nil {
+		return 0, err
+	}
+	if v.kind != regDWORD && v.kind != regQWORD {
+		return 0, fmt.Errorf(`%s\%s: not an integer value`, h.path, name)
+	}
+	return v.num, nil
+}
+
+func (h *regFileHandle) GetBinaryValue(name string) ([]byte, error) {
+	v, err := h.value(name)
+	if err != nil {
+		return nil, err
+	}
+	if v.kind != regBinary {
+		return nil, fmt.Errorf(`%s\%s: not a binary value`, h.path, name)
+	}
+	return v.bin, nil
+}
+
+// ReadSubKeyNames
+// The keys right below this one, sorted, as cased in the file,
+// those only implied by a deeper key included.
+func (h *regFileHandle) ReadSubKeyNames() (names []string, err error) {
+	h.file.mu.Lock()
+	defer h.file.mu.Unlock()
+
+	prefix := strings.ToLower(h.path) + `\`
+	seen := map[string]bool{}
+	for p, k := range h.file.keys {
+		rest, ok := strings.CutPrefix(p, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(k.path[len(prefix):], `\`)
+		if !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (h *regFileHandle) Close() error {
+	return nil
+}
+
+type recordingKey struct {
+	RegistryKey
+	file *RegFile
+	path string
+}
+
+func (k *recordingKey) GetStringValue(name string) (string, error) {
+	val, err := k.RegistryKey.GetStringValue(name)
+	if err == nil {
+		k.file.set(k.path, &regValue{name: name, kind: regSZ, str: val})
+	}
+	return val, err
+}
+
+func (k *recordingKey) GetBinaryValue(name string) ([]byte, error) {
+	val, err := k.RegistryKey.GetBinaryValue(name)
+	if err == nil {
+		k.file.set(k.path, &regValue{name: name, kind: regBinary, bin: val})
+	}
+	return val, err
+}
+
+// ReadSubKeyNames
+// Keeps the subkeys, too, so they're listed on replay even if none is opened.
+func (k *recordingKey) ReadSubKeyNames() ([]string, error) {
+	names, err := k.RegistryKey.ReadSubKeyNames()
+	if err != nil {
+		return nil, err
+	}
+
+	k.file.mu.Lock()
+	defer k.file.mu.Unlock()
+	for _, n := range names {
+		k.file.key(k.path + `\` + n)
+	}
+	return names, nil
+}
+
+func (k *recordingKey) GetIntegerValue(name string) (uint64, error) {
+	val, err := k.RegistryKey.GetIntegerValue(name)
+	if err == nil {
+		kind := regDWORD
+		if val > 0xFFFFFFFF {
+			kind = regQWORD
+		}
+		k.file.set(k.path, &regValue{name: name, kind: kind, num: val})
+	}
+	return val, err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////////////
+
+// exists
+// Whether path is a key, written out or only implied by a deeper one,
+// as regedit exports a key's parents only if they have values.
+// The caller holds the lock.
+func (f *RegFile) exists(path string) bool {
+	path = strings.ToLower(path)
+	if _, ok := f.keys[path]; ok {
+		return true
+	}
+	for p := range f.keys {
+		if strings.HasPrefix(p, path+`\`) {
+			return true
+		}
+	}
+	return false
+}
+
+// key
+// Get or create a key, the caller holds the lock.
+func (f *RegFile) key(path string) *regFileKey {
+	k, ok := f.keys[strings.ToLower(path)]
+	if !ok {
+		k = &regFileKey{path: path, values: map[string]*regValue{}}
+		f.keys[strings.ToLower(path)] = k
+	}
+	return k
+}
+
+func (f *RegFile) set(path string, v *regValue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.key(path).values[strings.ToLower(v.name)] = v
+}
+
+// decodeRegText
+// Strip the BOM and decode UTF-16 if needed.
+func decodeRegText(data []byte) string {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		order = binary.BigEndian
+	default:
+		return string(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF}))
+	}
+
+	data = data[2:]
+	u := make([]uint16, len(data)/2)
+	for i := range u {
+		u[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// parseRegValue
+// Parse a `"name"=data` or `@=data` line.
+// A nil value with no error means a deleted value.
+func parseRegValue(line string) (*regValue, error) {
+	v := &regValue{}
+
+	var rest string
+	switch {
+	case strings.HasPrefix(line, "@="):
+		rest = line[2:]
+	case strings.HasPrefix(line, `"`):
+		name, n, err := unquoteReg(line)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line[n:], "=") {
+			return nil, fmt.Errorf("missing '=' after value name %q", name)
+		}
+		v.name, rest = name, line[n+1:]
+	default:
+		return nil, fmt.Errorf("malformed value: %s", line)
+	}
+
+	switch {
+	case rest == "-":
+		return nil, nil
+
+	case strings.HasPrefix(rest, `"`):
+		s, _, err := unquoteReg(rest)
+		if err != nil {
+			return nil, err
+		}
+		v.kind, v.str = regSZ, s
+
+	case strings.HasPrefix(rest, "dword:"):
+		n, err := strconv.ParseUint(rest[6:], 16, 32)
+		if err != nil {
+			return nil, err
+		}
+		v.kind, v.num = regDWORD, n
+
+	case strings.HasPrefix(rest, "hex"):
+		kind := regBinary
+		spec, data, ok := strings.Cut(rest, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed hex value: %s", rest)
+		}
+		if spec != "hex" {
+			k, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(spec, "hex("), ")"), 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("malformed hex value type: %s", spec)
+			}
+			kind = int(k)
+		}
+
+		bin, err := hex.DecodeString(strings.ReplaceAll(data, ",", ""))
+		if err != nil {
+			return nil, err
+		}
+		v.kind, v.bin = kind, bin
+
+		switch kind {
+		case regExpandSZ, regSZ:
+			v.str = strings.TrimRight(utf16le(bin), "\x00")
+		case regDWORD:
+			bin = append(bin, make([]byte, 4)...)
+			v.num = uint64(binary.LittleEndian.Uint32(bin))
+		case regQWORD:
+			bin = append(bin, make([]byte, 8)...)
+			v.num = binary.LittleEndian.Uint64(bin)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported value data: %s", rest)
+	}
+
+	return v, nil
+}
+
+// unquoteReg
+// Unquote a leading .reg string, returning it and the bytes consumed.
+func unquoteReg(s string) (string, int, error) {
+	var b strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+			}
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated string: %s", s)
+}
+
+func utf16le(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+func (v *regValue) String() string {
+	name := `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v.name) + `"`
+	if v.name == "" {
+		name = "@"
+	}
+
+	switch v.kind {
+	case regSZ:
+		return name + `="` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v.str) + `"`
+	case regDWORD:
+		return fmt.Sprintf("%s=dword:%08x", name, v.num)
+	case regQWORD:
+		b := binary.LittleEndian.AppendUint64(nil, v.num)
+		return fmt.Sprintf("%s=hex(b):%s", name, hexList(b))
+	case regBinary:
+		return fmt.Sprintf("%s=hex:%s", name, hexList(v.bin))
+	default:
+		return fmt.Sprintf("%s=hex(%x):%s", name, v.kind, hexList(v.bin))
+	}
+}
+
+func hexList(b []byte) string {
+	s := make([]string, len(b))
+	for i := range b {
+		s[i] = fmt.Sprintf("%02x", b[i])
+	}
+	return strings.Join(s, ",")
+}
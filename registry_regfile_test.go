@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// A regedit export: UTF-16LE with a BOM, CRLF, wrapped hex data,
+// and no key for the parents of the ones with values.
+func loadRegFixture(t *testing.T) *RegFile {
+	t.Helper()
+	f, err := LoadRegFile("testdata/registry/regedit-utf16.reg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func openRegKey(t *testing.T, f *RegFile, path string) RegistryKey {
+	t.Helper()
+	k, err := f.OpenKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = k.Close() })
+	return k
+}
+
+func TestParseRegFile(t *testing.T) {
+	f := loadRegFixture(t)
+
+	t.Run("strings", func(t *testing.T) {
+		k := openRegKey(t, f, `HKLM\HARDWARE\DESCRIPTION\System\BIOS`)
+		for name, want := range map[string]string{
+			"BIOSVendor":        "Dell Inc.",
+			"systemproductname": "Latitude 7420", // names are case-insensitive
+		} {
+			if got, err := k.GetStringValue(name); err != nil || got != want {
+				t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+			}
+		}
+	})
+
+	t.Run("numbers", func(t *testing.T) {
+		for _, c := range []struct {
+			path, name string
+			want       uint64
+		}{
+			{`HKLM\HARDWARE\DESCRIPTION\System\BIOS`, "BiosMinorRelease", 0x15},
+			{`HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion`, "UBR", 3869},
+			{`HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion`, "InstallTime", 0x01d9b65c8f6ed000}, // hex(b)
+		} {
+			got, err := openRegKey(t, f, c.path).GetIntegerValue(c.name)
+			if err != nil || got != c.want {
+				t.Errorf("%s = %#x, %v, want %#x", c.name, got, err, c.want)
+			}
+		}
+	})
+
+	t.Run("hex continued", func(t *testing.T) {
+		k := openRegKey(t, f, `HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft\Windows NT\CurrentVersion`)
+
+		b, err := k.GetBinaryValue("DigitalProductId")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) != 52 || b[0] != 0xA4 || string(b[8:31]) != "00330-80000-00000-AA407" {
+			t.Errorf("DigitalProductId = % x", b)
+		}
+
+		// hex(2), over two lines
+		if got, err := k.GetStringValue("PathName"); err != nil || got != `C:\WINDOWS` {
+			t.Errorf("PathName = %q, %v", got, err)
+		}
+
+		// The wrong type is an error, not a zero value.
+		if _, err := k.GetIntegerValue("PathName"); err == nil {
+			t.Error("PathName read as an integer")
+		}
+	})
+
+	t.Run("multi-sz", func(t *testing.T) {
+		k := f.keys[`hkey_local_machine\system\currentcontrolset\services\lanmanserver\parameters`]
+		if k == nil {
+			t.Fatal("LanmanServer\\Parameters not parsed")
+		}
+		for name, want := range map[string]string{
+			"lanmanserver":     "SrvSvc\x00Browser\x00\x00",
+			"nullsessionpipes": "\x00",
+		} {
+			v := k.values[name]
+			if v == nil || v.kind != regMultiSZ || utf16le(v.bin) != want {
+				t.Errorf("%s = %+v, want %q", name, v, want)
+			}
+		}
+	})
+
+	t.Run("deleted key", func(t *testing.T) {
+		_, err := f.OpenKey(`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\Removed`)
+		if !errors.Is(err, ErrRegNotExist) {
+			t.Errorf("OpenKey(Removed) = %v, want ErrRegNotExist", err)
+		}
+	})
+}
+
+// regedit leaves out keys without values, e.g., Uninstall itself.
+func TestRegFileImpliedKeys(t *testing.T) {
+	f := loadRegFixture(t)
+
+	for _, path := range []string{
+		`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`,
+		`HKLM\SOFTWARE`,
+		`HKLM\system\CurrentControlSet\Services`,
+	} {
+		k, err := f.OpenKey(path)
+		if err != nil {
+			t.Errorf("OpenKey(%s) = %v", path, err)
+			continue
+		}
+		if _, err := k.GetStringValue("DisplayName"); !errors.Is(err, ErrRegNotExist) {
+			t.Errorf("%s has a value: %v", path, err)
+		}
+	}
+
+	k := openRegKey(t, f, `HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`)
+	names, err := k.ReadSubKeyNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"7-Zip", "{9A1B2C3D-0000-4E5F-8A9B-0C1D2E3F4A5B}"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Uninstall subkeys = %q, want %q", names, want)
+	}
+
+	// Implied keys are listed once, as cased in the file.
+	names, err = openRegKey(t, f, `hklm\software\microsoft`).ReadSubKeyNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Windows", "Windows NT"}; !slices.Equal(names, want) {
+		t.Errorf("Microsoft subkeys = %q, want %q", names, want)
+	}
+
+	// A prefix of a key name isn't a key.
+	if _, err := f.OpenKey(`HKLM\SOFTWARE\Micro`); !errors.Is(err, ErrRegNotExist) {
+		t.Errorf("OpenKey(Micro) = %v, want ErrRegNotExist", err)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build windows
+
+package main
+
+import (
+	"golang.org/x/sys/windows/registry"
+)
+
+// WinRegistry
+// The live RegistrySource, backed by the local registry.
+type WinRegistry struct{}
+
+var winRegHives = map[string]registry.Key{
+	"HKEY_LOCAL_MACHINE":  registry.LOCAL_MACHINE,
+	"HKEY_CURRENT_USER":   registry.CURRENT_USER,
+	"HKEY_CLASSES_ROOT":   registry.CLASSES_ROOT,
+	"HKEY_USERS":          registry.USERS,
+	"HKEY_CURRENT_CONFIG": registry.CURRENT_CONFIG,
+}
+
+func (WinRegistry) OpenKey(path string) (RegistryKey, error) {
+	hive, subkey, err := splitRegPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := registry.OpenKey(winRegHives[hive], subkey,
+		registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegistryReader{Key: key}, nil
+}
+
+//******************************************************************************
+// Registry Reader
+//******************************************************************************
+
+type RegistryReader struct {
+	Key registry.Key
+}
+
+func (r *RegistryReader) GetStringValue(name string) (string, error) {
+	val, _, err := r.Key.GetStringValue(name)
+	if err != nil {
+		return "", err
+	}
+	return val, nil
+}
+
+func (r *RegistryReader) GetIntegerValue(name string) (uint64, error) {
+	val, _, err := r.Key.GetIntegerValue(name)
+	if err != nil {
+		return 0, err
+	}
+	return val, nil
+}
+
+func (r *RegistryReader) GetBinaryValue(name string) ([]byte, error) {
+	val, _, err := r.Key.GetBinaryValue(name)
+	if err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+func (r *RegistryReader) ReadSubKeyNames() ([]string, error) {
+	return r.Key.ReadSubKeyNames(-1)
+}
+
+func (r *RegistryReader) Close() error {
+	return r.Key.Close()
+}
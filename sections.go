@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Section
+// A part of the report, collected on its own
+// and keyed by Name in every output format.
+// Collect, Table, the serializers and the HTML report all go by the sections
+// registered with RegisterSection, so a section can be added, or compiled out,
+// along with its own file.
+type Section struct {
+	Name    string   // key in every output format and Diagnostics, e.g., "CPUs"
+	Key     string   // as in -sections, e.g., "cpu"
+	Aliases []string // other spellings people are likely to type
+	Order   int      // position in the report, lowest first
+
+	// Keys of sections to be collected first, earlier in Order.
+	// Hidden ones are pulled in as needed,
+	// others have to be selected, too, or this section is skipped.
+	Deps []string
+
+	// Time limit, 0 means DefaultSectionTimeout.
+	// CollectOptions.SectionTimeout, if set, overrides it.
+	Timeout time.Duration
+
+	Hidden   bool // only a dependency of others, never reported
+	Optional bool // only collected when asked for, e.g., the product key
+
+	// New returns the empty node, a pointer, nil if there's no node of its own.
+	// Collect fills it in, see Specs.node for the nodes of its Deps.
+	New     func() any
+	Collect func(ctx context.Context, src Sources, s *Specs, node any) error
+}
+
+var sections []*Section
+
+// RegisterSection
+// Add sec to the report, meant to be called from a package-level var,
+// so it's done before the CLI and the launcher run from init().
+func RegisterSection(sec Section) *Section {
+	for _, v := range sections {
+		if v.Key == sec.Key {
+			panic("section registered twice: " + sec.Key)
+		}
+	}
+
+	i, _ := slices.BinarySearchFunc(sections, sec.Order+1, func(v *Section, order int) int {
+		return v.Order - order
+	})
+	sections = slices.Insert(sections, i, &sec)
+
+	return &sec
+}
+
+func lookupSection(key string) *Section {
+	for _, v := range sections {
+		if v.Key == key {
+			return v
+		}
+	}
+	return nil
+}
+
+// SectionKeys
+// Keys accepted by CollectOptions.Sections, in report order.
+func SectionKeys() (keys []string) {
+	for _, v := range sections {
+		if !v.Hidden {
+			keys = append(keys, v.Key)
+		}
+	}
+	return keys
+}
+
+// ParseSections
+// Turn comma-separated include and exclude lists into CollectOptions.Sections.
+// An empty include list means every section but the optional ones.
+// Leaving out a section another one asked for depends on is an error,
+// e.g., -sections key without windows.
+func ParseSections(include, exclude string) ([]string, error) {
+	in, err := splitSections(include)
+	if err != nil {
+		return nil, err
+	}
+	ex, err := splitSections(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, v := range sections {
+		switch {
+		case v.Hidden, slices.Contains(ex, v.Key):
+		case len(in) == 0 && !v.Optional, slices.Contains(in, v.Key):
+			keys = append(keys, v.Key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no section left to collect")
+	}
+	for _, k := range keys {
+		for _, d := range lookupSection(k).Deps {
+			if !lookupSection(d).Hidden && !slices.Contains(keys, d) {
+				return nil, fmt.Errorf("section %q needs %q, too", k, d)
+			}
+		}
+	}
+	return keys, nil
+}
+
+func splitSections(list string) (keys []string, err error) {
+outer:
+	for _, k := range strings.Split(list, ",") {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		for _, v := range sections {
+			if !v.Hidden && (v.Key == k || slices.Contains(v.Aliases, k)) {
+				keys = append(keys, v.Key)
+				continue outer
+			}
+		}
+		return nil, fmt.Errorf("unknown section %q, expecting one of %s",
+			k, strings.Join(SectionKeys(), ", "))
+	}
+	return keys, nil
+}
+
+// resolveSections
+// The sections to run for the given keys, see CollectOptions.Sections,
+// along with their hidden dependencies, in report order.
+func resolveSections(keys []string) []*Section {
+	if len(keys) == 0 {
+		keys, _ = ParseSections("", "")
+	}
+
+	selected := map[string]bool{}
+	var pull func(key string)
+	pull = func(key string) {
+		sec := lookupSection(key)
+		if sec == nil || selected[key] {
+			return
+		}
+		selected[key] = true
+		for _, d := range sec.Deps {
+			if dep := lookupSection(d); dep != nil && dep.Hidden {
+				pull(d)
+			}
+		}
+	}
+	for _, k := range keys {
+		pull(k)
+	}
+
+	// Drop whatever is missing a dependency, until nothing else is.
+	for dropped := true; dropped; {
+		dropped = false
+		for k := range selected {
+			for _, d := range lookupSection(k).Deps {
+				if !selected[d] {
+					delete(selected, k)
+					dropped = true
+					break
+				}
+			}
+		}
+	}
+
+	var run []*Section
+	for _, v := range sections {
+		if selected[v.Key] {
+			run = append(run, v)
+		}
+	}
+	return run
+}
+
+// node
+// The collected node of a section by key, nil if not collected.
+func (s *Specs) node(key string) any {
+	return s.nodes[key]
+}
+
+// document
+// The report as a struct, one field per collected section in report order,
+// then Diagnostics, as if Specs had been declared with those fields.
+// The serializers and Table go by its fields and tags,
+// only the former ask for it stamped with the collection time.
+func (s *Specs) document(stamped bool) any {
+	var fields []reflect.StructField
+	var values []reflect.Value
+
+	// Only when not default, so -load knows how to read it back.
+	if OutputUnits != UnitsDefault {
+		fields = append(fields, documentField("Units", reflect.TypeOf(OutputUnits)))
+		values = append(values, reflect.ValueOf(OutputUnits))
+	}
+
+	if stamped && !s.collected.IsZero() {
+		fields = append(fields, documentField("Collected", reflect.TypeFor[string]()))
+		values = append(values, reflect.ValueOf(s.collected.Format(time.RFC3339)))
+	}
+
+	for _, v := range sections {
+		if n := s.nodes[v.Key]; n != nil && !v.Hidden {
+			fields = append(fields, documentField(v.Name, reflect.TypeOf(n)))
+			values = append(values, reflect.ValueOf(n))
+		}
+	}
+	fields = append(fields, documentField("Diagnostics", reflect.TypeOf(s.Diagnostics)))
+	values = append(values, reflect.ValueOf(s.Diagnostics))
+
+	doc := reflect.New(reflect.StructOf(fields))
+	for i, v := range values {
+		doc.Elem().Field(i).Set(v)
+	}
+	return doc.Interface()
+}
+
+// load
+// The other way around, decode fills in a document with every known section,
+// and whichever sections it has are taken as collected.
+// decode is called twice, to read the units and collection time first.
+func (s *Specs) load(decode func(doc any) error) error {
+	var fields []reflect.StructField
+	var keys []string
+
+	for _, v := range sections {
+		if v.New != nil && !v.Hidden {
+			fields = append(fields, documentField(v.Name, reflect.TypeOf(v.New())))
+			keys = append(keys, v.Key)
+		}
+	}
+	fields = append(fields, documentField("Diagnostics", reflect.TypeOf(s.Diagnostics)))
+
+	// Sizes and speeds depend on it, see Units.
+	var head struct {
+		Units     Units  `json:"Units"     yaml:"units"     toml:"Units"`
+		Collected string `json:"Collected" yaml:"collected" toml:"Collected"`
+	}
+	if err := decode(&head); err != nil {
+		return err
+	}
+	inputUnits, s.units = head.Units, head.Units
+	defer func() { inputUnits = UnitsDefault }()
+
+	// Older reports don't have it.
+	s.collected = time.Time{}
+	if head.Collected != "" {
+		t, err := time.Parse(time.RFC3339, head.Collected)
+		if err != nil {
+			return fmt.Errorf("Collected: %w", err)
+		}
+		s.collected = t
+	}
+
+	doc := reflect.New(reflect.StructOf(fields))
+	if err := decode(doc.Interface()); err != nil {
+		return err
+	}
+
+	s.nodes = map[string]any{}
+	for i, k := range keys {
+		if f := doc.Elem().Field(i); !f.IsNil() {
+			s.nodes[k] = f.Interface()
+		}
+	}
+	s.Diagnostics = doc.Elem().Field(len(keys)).Interface().(Diagnostics)
+
+	return nil
+}
+
+func documentField(name string, t reflect.Type) reflect.StructField {
+	return reflect.StructField{
+		Name: name,
+		Type: t,
+		Tag: reflect.StructTag(fmt.Sprintf(
+			`json:"%s,omitempty" yaml:"%s,omitempty" toml:"%s,omitempty"`,
+			name, strings.ToLower(name), name)),
+	}
+}
+
+// templateData
+// The report for text/template, every collected section by name,
+// e.g., {{.Windows.CSName}}, along with
+// .User, as in user@DOMAIN, .Host, the device name, .Time, as in file names,
+// and .Diagnostics.
+func (s *Specs) templateData(at time.Time) map[string]any {
+	var username, host string
+	if u, ok := s.node("user").(*CurrentUser); ok {
+		username = u.Username
+	}
+	if w, ok := s.node("windows").(*Windows); ok {
+		host = w.CSName
+	}
+
+	data := map[string]any{
+		"User":        userAtHost.ReplaceAllString(username, "$2@$1"),
+		"Host":        host,
+		"Time":        at.Format("20060102T150405-0700"),
+		"Diagnostics": s.Diagnostics,
+	}
+	for _, v := range sections {
+		if n := s.nodes[v.Key]; n != nil && !v.Hidden {
+			data[v.Name] = n
+		}
+	}
+	return data
+}
+
+// userAtHost
+// DOMAIN\user, to be made user@DOMAIN.
+var userAtHost = regexp.MustCompile(`([^\\]+)\\([^\\]+)`)
+
+// keep
+// Drop every section but the given ones, see CollectOptions.Sections.
+func (s *Specs) keep(keys []string) {
+	for k := range s.nodes {
+		if !slices.Contains(keys, k) {
+			delete(s.nodes, k)
+		}
+	}
+}
@@ -1,16 +1,23 @@
-//go:build windows && cli
+//go:build cli
 
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/BurntSushi/toml"
 	"github.com/docker/go-units"
 	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 func (s *Specs) JSON() (string, error) {
-	jsonData, err := json.Marshal(&s)
+	jsonData, err := json.Marshal(s.document(true))
 	if err != nil {
 		return "", err
 	}
@@ -18,7 +25,7 @@ func (s *Specs) JSON() (string, error) {
 }
 
 func (s *Specs) YAML() (string, error) {
-	yamlData, err := yaml.Marshal(&s)
+	yamlData, err := yaml.Marshal(s.document(true))
 	if err != nil {
 		return "", err
 	}
@@ -26,30 +33,107 @@ func (s *Specs) YAML() (string, error) {
 }
 
 func (s *Specs) TOML() (string, error) {
-	tomlData, err := toml.Marshal(&s)
+	tomlData, err := toml.Marshal(s.document(true))
 	if err != nil {
 		return "", err
 	}
 	return string(tomlData), nil
 }
 
+// Load
+// Read back a report written as JSON, YAML or TOML, format being one of them,
+// instead of collecting it.
+// Sizes and speeds are scaled back, as far as the written units allow.
+func (s *Specs) Load(data []byte, format string) error {
+	switch format {
+	case "json":
+		return s.load(func(doc any) error { return json.Unmarshal(data, doc) })
+	case "yaml":
+		return s.load(func(doc any) error { return yaml.Unmarshal(data, doc) })
+	case "toml":
+		return s.load(func(doc any) error { return toml.Unmarshal(data, doc) })
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// LoadFile
+// Load a report by its extension, .json, .yaml, .yml or .toml,
+// or from stdin if path is "-", guessing the format otherwise.
+func (s *Specs) LoadFile(path string) error {
+	var data []byte
+	var err error
+
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	switch format {
+	case "json", "yaml", "toml":
+	case "yml":
+		format = "yaml"
+	default:
+		var v map[string]any
+		switch {
+		case bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+			format = "json"
+		case toml.Unmarshal(data, &v) == nil:
+			format = "toml"
+		default:
+			format = "yaml"
+		}
+	}
+
+	if err := s.Load(data, format); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // CPU
 ////////////////////////////////////////////////////////////////////////////////
 
 func (c CPUMaxClockSpeed) MarshalJSON() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return json.Marshal(clockSpeedUnits.value(uint64(c)))
+	}
 	return json.Marshal(float64(c) / 1e3) // already 3 decimal digits
 }
 
 // MarshalYAML Don't use yaml.Marshal for MarshalYAML()!
 func (c CPUMaxClockSpeed) MarshalYAML() (any, error) {
+	if OutputUnits != UnitsDefault {
+		return clockSpeedUnits.value(uint64(c)), nil
+	}
 	return float64(c) / 1e3, nil // already 3 decimal digits
 }
 
 func (c CPUMaxClockSpeed) MarshalTOML() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return toml.Marshal(clockSpeedUnits.value(uint64(c)))
+	}
 	return toml.Marshal(float64(c) / 1e3) // already 3 decimal digits
 }
 
+func (c *CPUMaxClockSpeed) UnmarshalJSON(b []byte) error {
+	return unmarshalScaledJSON(b, c, clockSpeedUnits)
+}
+
+func (c *CPUMaxClockSpeed) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalScaledYAML(value, c, clockSpeedUnits)
+}
+
+func (c *CPUMaxClockSpeed) UnmarshalTOML(v any) error {
+	return unmarshalScaledTOML(v, c, clockSpeedUnits)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // L2 & L3 cache size
 ////////////////////////////////////////////////////////////////////////////////
@@ -57,26 +141,65 @@ func (c CPUMaxClockSpeed) MarshalTOML() ([]byte, error) {
 // WMI returns L2 and L3 cache size in KiB
 
 func (c L2CacheSize) MarshalJSON() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return json.Marshal(cacheSizeUnits.value(uint64(c)))
+	}
 	return json.Marshal(int64(c) / units.KiB)
 }
 func (c L3CacheSize) MarshalJSON() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return json.Marshal(cacheSizeUnits.value(uint64(c)))
+	}
 	return json.Marshal(int64(c) / units.KiB)
 }
 
 func (c L2CacheSize) MarshalYAML() (any, error) {
+	if OutputUnits != UnitsDefault {
+		return cacheSizeUnits.value(uint64(c)), nil
+	}
 	return int64(c) / units.KiB, nil
 }
 func (c L3CacheSize) MarshalYAML() (any, error) {
+	if OutputUnits != UnitsDefault {
+		return cacheSizeUnits.value(uint64(c)), nil
+	}
 	return int64(c) / units.KiB, nil
 }
 
 func (c L2CacheSize) MarshalTOML() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return toml.Marshal(cacheSizeUnits.value(uint64(c)))
+	}
 	return toml.Marshal(int64(c) / units.KiB)
 }
 func (c L3CacheSize) MarshalTOML() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return toml.Marshal(cacheSizeUnits.value(uint64(c)))
+	}
 	return toml.Marshal(int64(c) / units.KiB)
 }
 
+func (c *L2CacheSize) UnmarshalJSON(b []byte) error {
+	return unmarshalScaledJSON(b, c, cacheSizeUnits)
+}
+func (c *L3CacheSize) UnmarshalJSON(b []byte) error {
+	return unmarshalScaledJSON(b, c, cacheSizeUnits)
+}
+
+func (c *L2CacheSize) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalScaledYAML(value, c, cacheSizeUnits)
+}
+func (c *L3CacheSize) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalScaledYAML(value, c, cacheSizeUnits)
+}
+
+func (c *L2CacheSize) UnmarshalTOML(v any) error {
+	return unmarshalScaledTOML(v, c, cacheSizeUnits)
+}
+func (c *L3CacheSize) UnmarshalTOML(v any) error {
+	return unmarshalScaledTOML(v, c, cacheSizeUnits)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Memory
 ////////////////////////////////////////////////////////////////////////////////
@@ -93,36 +216,157 @@ func (d DIMMType) MarshalTOML() ([]byte, error) {
 	return toml.Marshal(d.String())
 }
 
+// UnmarshalText
+// Covers JSON, YAML and TOML, all of them written as a string.
+func (d *DIMMType) UnmarshalText(b []byte) error {
+	for i := range DIMMType(256) {
+		if i.String() == string(b) {
+			*d = i
+			return nil
+		}
+	}
+	*d = 0 // unknown
+	return nil
+}
+
 // MarshalJSON
 // Mind the int64() to prevent stack overflow
 func (d DIMMCapacity) MarshalJSON() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return json.Marshal(memorySizeUnits.value(uint64(d)))
+	}
 	return json.Marshal(int64(d) / units.GiB)
 }
 
 func (d DIMMCapacity) MarshalYAML() (any, error) {
+	if OutputUnits != UnitsDefault {
+		return memorySizeUnits.value(uint64(d)), nil
+	}
 	return int64(d) / units.GiB, nil
 }
 
 func (d DIMMCapacity) MarshalTOML() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return toml.Marshal(memorySizeUnits.value(uint64(d)))
+	}
 	return toml.Marshal(int64(d) / units.GiB)
 }
 
+func (d *DIMMCapacity) UnmarshalJSON(b []byte) error {
+	return unmarshalScaledJSON(b, d, memorySizeUnits)
+}
+
+func (d *DIMMCapacity) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalScaledYAML(value, d, memorySizeUnits)
+}
+
+func (d *DIMMCapacity) UnmarshalTOML(v any) error {
+	return unmarshalScaledTOML(v, d, memorySizeUnits)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// System
+////////////////////////////////////////////////////////////////////////////////
+
+func (c ChassisType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c ChassisType) MarshalYAML() (any, error) {
+	return c.String(), nil
+}
+
+func (c ChassisType) MarshalTOML() ([]byte, error) {
+	return toml.Marshal(c.String())
+}
+
+// UnmarshalText
+// Covers JSON, YAML and TOML, all of them written as a string.
+func (c *ChassisType) UnmarshalText(b []byte) error {
+	for i := range ChassisType(256) {
+		if i.String() == string(b) {
+			*c = i
+			return nil
+		}
+	}
+	*c = 0 // unknown
+	return nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Disk
 ////////////////////////////////////////////////////////////////////////////////
 
 func (d DiskSize) MarshalJSON() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return json.Marshal(diskSizeUnits.value(uint64(d)))
+	}
 	return json.Marshal(int64(d) / units.GB)
 }
 
 func (d DiskSize) MarshalYAML() (any, error) {
+	if OutputUnits != UnitsDefault {
+		return diskSizeUnits.value(uint64(d)), nil
+	}
 	return int64(d) / units.GB, nil
 }
 
 func (d DiskSize) MarshalTOML() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return toml.Marshal(diskSizeUnits.value(uint64(d)))
+	}
 	return toml.Marshal(int64(d) / units.GB)
 }
 
+func (d *DiskSize) UnmarshalJSON(b []byte) error {
+	return unmarshalScaledJSON(b, d, diskSizeUnits)
+}
+
+func (d *DiskSize) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalScaledYAML(value, d, diskSizeUnits)
+}
+
+func (d *DiskSize) UnmarshalTOML(v any) error {
+	return unmarshalScaledTOML(v, d, diskSizeUnits)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Installed software
+////////////////////////////////////////////////////////////////////////////////
+
+func (p ProgramSize) MarshalJSON() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return json.Marshal(programSizeUnits.value(uint64(p)))
+	}
+	return json.Marshal(int64(p) * units.KiB / units.MiB)
+}
+
+func (p ProgramSize) MarshalYAML() (any, error) {
+	if OutputUnits != UnitsDefault {
+		return programSizeUnits.value(uint64(p)), nil
+	}
+	return int64(p) * units.KiB / units.MiB, nil
+}
+
+func (p ProgramSize) MarshalTOML() ([]byte, error) {
+	if OutputUnits != UnitsDefault {
+		return toml.Marshal(programSizeUnits.value(uint64(p)))
+	}
+	return toml.Marshal(int64(p) * units.KiB / units.MiB)
+}
+
+func (p *ProgramSize) UnmarshalJSON(b []byte) error {
+	return unmarshalScaledJSON(b, p, programSizeUnits)
+}
+
+func (p *ProgramSize) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalScaledYAML(value, p, programSizeUnits)
+}
+
+func (p *ProgramSize) UnmarshalTOML(v any) error {
+	return unmarshalScaledTOML(v, p, programSizeUnits)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Windows
 ////////////////////////////////////////////////////////////////////////////////
@@ -138,3 +382,61 @@ func (d WinInstallDate) MarshalYAML() (any, error) {
 func (d WinInstallDate) MarshalTOML() ([]byte, error) {
 	return toml.Marshal(d.String())
 }
+
+// UnmarshalText
+// Back from RFC 3339 to CIM datetime, e.g., 20240131235959.000000+420.
+// Covers JSON, YAML and TOML, all of them written as a string.
+func (d *WinInstallDate) UnmarshalText(b []byte) error {
+	if string(b) == "N/A" {
+		*d = ""
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, string(b))
+	if err != nil {
+		return err
+	}
+
+	_, offset := t.Zone()
+	*d = WinInstallDate(fmt.Sprintf("%s.000000%+04d",
+		t.Format("20060102150405"), offset/60))
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Scaled numbers
+////////////////////////////////////////////////////////////////////////////////
+
+// unmarshalScaled*
+// Undo the scaling of the Marshal* methods above, as the report says,
+// see Units.
+// Whatever was cut off by the default integer division is lost for good.
+
+func unmarshalScaledJSON[T ~uint64](b []byte, dst *T, q quantity) error {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	return unmarshalScaled(v, dst, q)
+}
+
+func unmarshalScaledYAML[T ~uint64](value *yaml.Node, dst *T, q quantity) error {
+	var v any
+	if err := value.Decode(&v); err != nil {
+		return err
+	}
+	return unmarshalScaled(v, dst, q)
+}
+
+func unmarshalScaledTOML[T ~uint64](v any, dst *T, q quantity) error {
+	return unmarshalScaled(v, dst, q)
+}
+
+func unmarshalScaled[T ~uint64](v any, dst *T, q quantity) error {
+	n, err := q.parse(v, inputUnits)
+	if err != nil {
+		return err
+	}
+	*dst = T(n)
+	return nil
+}
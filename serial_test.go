@@ -0,0 +1,68 @@
+//go:build cli
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Saved reports say when they were collected, in the collector's time zone,
+// and load back as they were written.
+func TestCollectedRoundTrip(t *testing.T) {
+	src, err := ReplaySources("testdata/replay/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Specs
+	if err := s.Collect(context.Background(), src, CollectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(s.collected) > time.Minute {
+		t.Fatalf("collected = %v, want now", s.collected)
+	}
+	s.collected = time.Date(2025, 6, 2, 9, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	for format, write := range map[string]func(*Specs) (string, error){
+		"json": (*Specs).JSON,
+		"yaml": (*Specs).YAML,
+		"toml": (*Specs).TOML,
+	} {
+		t.Run(format, func(t *testing.T) {
+			text, err := write(&s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(text, "2025-06-02T09:30:00+02:00") {
+				t.Fatalf("no collection time in\n%s", text)
+			}
+
+			var got Specs
+			if err := got.Load([]byte(text), format); err != nil {
+				t.Fatal(err)
+			}
+			if !got.collected.Equal(s.collected) {
+				t.Errorf("collected = %v, want %v", got.collected, s.collected)
+			}
+			if again, err := write(&got); err != nil || again != text {
+				t.Errorf("written again differs, %v:\n%s", err, again)
+			}
+		})
+	}
+
+	// Older reports don't say, a malformed time is an error.
+	var old Specs
+	if err := old.Load([]byte(`{"Windows": {"CSName": "PC01"}}`), "json"); err != nil || !old.collected.IsZero() {
+		t.Errorf("without Collected: %v, %v", old.collected, err)
+	}
+	if err := old.Load([]byte(`{"Collected": "June 2nd"}`), "json"); err == nil {
+		t.Error("malformed Collected: no error")
+	}
+
+	// Nor is it part of the report itself.
+	if text := s.TextPretty(); strings.Contains(text, "Collected") {
+		t.Errorf("Collected in the text report:\n%s", text)
+	}
+}
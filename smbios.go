@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SMBIOS
+// The decoded SMBIOS/DMI structure table, only the types we care about.
+// See: https://www.dmtf.org/sites/default/files/standards/documents/DSP0134_3.4.0.pdf
+//
+// Fields are decoded according to each structure's length,
+// so older tables simply leave the newer fields zeroed.
+type SMBIOS struct {
+	BIOS          []SMBIOSBIOS         // type 0
+	Systems       []SMBIOSSystem       // type 1
+	Baseboards    []SMBIOSBaseboard    // type 2
+	Chassis       []SMBIOSChassis      // type 3
+	Processors    []SMBIOSProcessor    // type 4
+	Caches        []SMBIOSCache        // type 7
+	MemoryArrays  []SMBIOSMemoryArray  // type 16
+	MemoryDevices []SMBIOSMemoryDevice // type 17
+	PowerSupplies []SMBIOSPowerSupply  // type 39
+	Structures    []SMBIOSStructure    // all of them, undecoded
+}
+
+// SMBIOSStructure
+// A raw structure: its formatted area, header included, and its strings.
+type SMBIOSStructure struct {
+	Type      uint8
+	Handle    uint16
+	Formatted []byte
+	Strings   []string
+}
+
+// Table 6
+type SMBIOSBIOS struct {
+	Vendor       string
+	Version      string
+	ReleaseDate  string
+	MajorRelease uint8
+	MinorRelease uint8
+}
+
+// Table 10
+type SMBIOSSystem struct {
+	Manufacturer string
+	ProductName  string
+	Version      string
+	SerialNumber string
+	UUID         string
+	SKU          string
+	Family       string
+}
+
+// Table 13
+type SMBIOSBaseboard struct {
+	Manufacturer string
+	Product      string
+	Version      string
+	SerialNumber string
+	AssetTag     string
+}
+
+// Table 16
+type SMBIOSChassis struct {
+	Manufacturer string
+	Type         ChassisType
+	Version      string
+	SerialNumber string
+	AssetTag     string
+}
+
+// Table 21
+type SMBIOSProcessor struct {
+	SocketDesignation string
+	Manufacturer      string
+	Version           string
+	MaxSpeed          uint64 // MHz
+	CurrentSpeed      uint64 // MHz
+	Populated         bool
+	L1Handle          uint16
+	L2Handle          uint16
+	L3Handle          uint16
+	SerialNumber      string
+	PartNumber        string
+	CoreCount         uint64
+	ThreadCount       uint64
+}
+
+// Table 36
+type SMBIOSCache struct {
+	Handle            uint16
+	SocketDesignation string
+	Level             uint8
+	InstalledSize     uint64 // bytes
+}
+
+// Table 71
+type SMBIOSMemoryArray struct {
+	Handle        uint16
+	Use           uint8  // 3 is system memory
+	MaxCapacity   uint64 // bytes
+	NumberOfSlots uint64
+}
+
+// Table 74
+type SMBIOSMemoryDevice struct {
+	ArrayHandle   uint16
+	Size          uint64 // bytes, zero if the slot is empty
+	DeviceLocator string
+	BankLocator   string
+	MemoryType    uint8
+	Speed         uint64 // MT/s
+	Manufacturer  string
+	SerialNumber  string
+	PartNumber    string
+}
+
+// Table 117
+type SMBIOSPowerSupply struct {
+	Location         string
+	DeviceName       string
+	Manufacturer     string
+	SerialNumber     string
+	ModelPartNumber  string
+	MaxPowerCapacity uint64 // watts, zero if unknown
+}
+
+const smbiosSystemMemory = 3
+
+////////////////////////////////////////////////////////////////////////////////
+// Public Methods
+////////////////////////////////////////////////////////////////////////////////
+
+// ParseSMBIOS
+// Decode a raw structure table, as found in /sys/firmware/dmi/tables/DMI,
+// or in the SMBIOSTableData of GetSystemFirmwareTable's RawSMBIOSData.
+func ParseSMBIOS(table []byte) (*SMBIOS, error) {
+	t := &SMBIOS{}
+
+	for i := 0; i+4 <= len(table); {
+		typ, length := table[i], int(table[i+1])
+		if length < 4 || i+length > len(table) {
+			return nil, fmt.Errorf("smbios: truncated structure at offset %d", i)
+		}
+
+		s := SMBIOSStructure{
+			Type:      typ,
+			Handle:    binary.LittleEndian.Uint16(table[i+2:]),
+			Formatted: table[i : i+length],
+		}
+
+		// The string-set ends with a double NUL,
+		// a structure without strings has two NULs right away.
+		j := i + length
+		end := bytes.Index(table[j:], []byte{0, 0})
+		if end < 0 {
+			return nil, fmt.Errorf("smbios: unterminated strings at offset %d", j)
+		}
+		if end > 0 {
+			s.Strings = strings.Split(string(table[j:j+end]), "\x00")
+		}
+
+		t.Structures = append(t.Structures, s)
+		t.decode(s)
+
+		i = j + end + 2
+
+		if typ == 127 { // end-of-table
+			break
+		}
+	}
+
+	if len(t.Structures) == 0 {
+		return nil, errors.New("smbios: empty table")
+	}
+
+	return t, nil
+}
+
+// ParseRawSMBIOSData
+// Decode the buffer returned by GetSystemFirmwareTable('RSMB'),
+// i.e., an 8-byte header followed by the structure table.
+func ParseRawSMBIOSData(data []byte) (*SMBIOS, error) {
+	table, err := rawSMBIOSTable(data)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSMBIOS(table)
+}
+
+// Cache
+// The cache a processor's L1Handle, L2Handle or L3Handle refers to.
+// False for 0xFFFF, i.e., no such cache, or a handle to nothing.
+func (t *SMBIOS) Cache(handle uint16) (SMBIOSCache, bool) {
+	if handle == 0xFFFF {
+		return SMBIOSCache{}, false
+	}
+	for _, c := range t.Caches {
+		if c.Handle == handle {
+			return c, true
+		}
+	}
+	return SMBIOSCache{}, false
+}
+
+// rawSMBIOSTable
+// Strip the RawSMBIOSData header.
+func rawSMBIOSTable(data []byte) ([]byte, error) {
+	if len(data) < 8 {
+		return nil, errors.New("smbios: truncated RawSMBIOSData")
+	}
+
+	n := int(binary.LittleEndian.Uint32(data[4:]))
+	if 8+n > len(data) {
+		return nil, errors.New("smbios: truncated RawSMBIOSData")
+	}
+
+	return data[8 : 8+n], nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Decoders
+////////////////////////////////////////////////////////////////////////////////
+
+func (t *SMBIOS) decode(s SMBIOSStructure) {
+	switch s.Type {
+	case 0:
+		t.BIOS = append(t.BIOS, SMBIOSBIOS{
+			Vendor:       s.str(0x04),
+			Version:      s.str(0x05),
+			ReleaseDate:  s.str(0x08),
+			MajorRelease: s.byte(0x14),
+			MinorRelease: s.byte(0x15),
+		})
+
+	case 1:
+		t.Systems = append(t.Systems, SMBIOSSystem{
+			Manufacturer: s.str(0x04),
+			ProductName:  s.str(0x05),
+			Version:      s.str(0x06),
+			SerialNumber: s.str(0x07),
+			UUID:         s.uuid(0x08),
+			SKU:          s.str(0x19),
+			Family:       s.str(0x1A),
+		})
+
+	case 2:
+		t.Baseboards = append(t.Baseboards, SMBIOSBaseboard{
+			Manufacturer: s.str(0x04),
+			Product:      s.str(0x05),
+			Version:      s.str(0x06),
+			SerialNumber: s.str(0x07),
+			AssetTag:     s.str(0x08),
+		})
+
+	case 3:
+		t.Chassis = append(t.Chassis, SMBIOSChassis{
+			Manufacturer: s.str(0x04),
+			Type:         ChassisType(s.byte(0x05) & 0x7F), // bit 7 is the lock
+			Version:      s.str(0x06),
+			SerialNumber: s.str(0x07),
+			AssetTag:     s.str(0x08),
+		})
+
+	case 4:
+		p := SMBIOSProcessor{
+			SocketDesignation: s.str(0x04),
+			Manufacturer:      s.str(0x07),
+			Version:           s.str(0x10),
+			MaxSpeed:          uint64(s.word(0x14)),
+			CurrentSpeed:      uint64(s.word(0x16)),
+			Populated:         s.byte(0x18)&0x40 != 0,
+			L1Handle:          s.word(0x1A),
+			L2Handle:          s.word(0x1C),
+			L3Handle:          s.word(0x1E),
+			SerialNumber:      s.str(0x20),
+			PartNumber:        s.str(0x22),
+			CoreCount:         uint64(s.byte(0x23)),
+			ThreadCount:       uint64(s.byte(0x25)),
+		}
+		// 0xFF means see the 3.0 word-sized counts.
+		if p.CoreCount == 0xFF {
+			p.CoreCount = uint64(s.word(0x2A))
+		}
+		if p.ThreadCount == 0xFF {
+			p.ThreadCount = uint64(s.word(0x2E))
+		}
+		t.Processors = append(t.Processors, p)
+
+	case 7:
+		size := cacheSize(uint32(s.word(0x09)), 15)
+		if s.word(0x09) == 0xFFFF {
+			size = cacheSize(s.dword(0x17), 31)
+		}
+		t.Caches = append(t.Caches, SMBIOSCache{
+			Handle:            s.Handle,
+			SocketDesignation: s.str(0x04),
+			Level:             uint8(s.word(0x05)&0x07) + 1,
+			InstalledSize:     size,
+		})
+
+	case 16:
+		// In KiB, unless it doesn't fit.
+		capacity := uint64(s.dword(0x07)) * 1024
+		if s.dword(0x07) == 0x80000000 {
+			capacity = s.qword(0x0F)
+		}
+		t.MemoryArrays = append(t.MemoryArrays, SMBIOSMemoryArray{
+			Handle:        s.Handle,
+			Use:           s.byte(0x05),
+			MaxCapacity:   capacity,
+			NumberOfSlots: uint64(s.word(0x0D)),
+		})
+
+	case 17:
+		var size uint64
+		switch w := s.word(0x0C); {
+		case w == 0xFFFF: // unknown
+		case w == 0x7FFF: // see extended size, in MiB
+			size = uint64(s.dword(0x1C)&0x7FFFFFFF) << 20
+		case w&0x8000 != 0: // KiB
+			size = uint64(w&0x7FFF) << 10
+		default: // MiB
+			size = uint64(w) << 20
+		}
+
+		speed := uint64(s.word(0x15))
+		if speed == 0xFFFF {
+			speed = uint64(s.dword(0x54))
+		}
+
+		t.MemoryDevices = append(t.MemoryDevices, SMBIOSMemoryDevice{
+			ArrayHandle:   s.word(0x04),
+			Size:          size,
+			DeviceLocator: s.str(0x10),
+			BankLocator:   s.str(0x11),
+			MemoryType:    s.byte(0x12),
+			Speed:         speed,
+			Manufacturer:  s.str(0x17),
+			SerialNumber:  s.str(0x18),
+			PartNumber:    s.str(0x1A),
+		})
+
+	case 39:
+		capacity := uint64(s.word(0x0C))
+		if capacity == 0x8000 {
+			capacity = 0
+		}
+		t.PowerSupplies = append(t.PowerSupplies, SMBIOSPowerSupply{
+			Location:         s.str(0x05),
+			DeviceName:       s.str(0x06),
+			Manufacturer:     s.str(0x07),
+			SerialNumber:     s.str(0x08),
+			ModelPartNumber:  s.str(0x0A),
+			MaxPowerCapacity: capacity,
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////////////
+
+// Out-of-range offsets read as zero, i.e., the field isn't there.
+
+func (s SMBIOSStructure) byte(off int) uint8 {
+	if off+1 > len(s.Formatted) {
+		return 0
+	}
+	return s.Formatted[off]
+}
+
+func (s SMBIOSStructure) word(off int) uint16 {
+	if off+2 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(s.Formatted[off:])
+}
+
+func (s SMBIOSStructure) dword(off int) uint32 {
+	if off+4 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(s.Formatted[off:])
+}
+
+func (s SMBIOSStructure) qword(off int) uint64 {
+	if off+8 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(s.Formatted[off:])
+}
+
+// str
+// Strings are referenced by a 1-based index, 0 means none.
+func (s SMBIOSStructure) str(off int) string {
+	i := int(s.byte(off))
+	if i == 0 || i > len(s.Strings) {
+		return ""
+	}
+	return strings.TrimSpace(s.Strings[i-1])
+}
+
+// uuid
+// Since SMBIOS 2.6, the first three fields are little-endian.
+// All zeros means not set, all ones means not present.
+func (s SMBIOSStructure) uuid(off int) string {
+	if off+16 > len(s.Formatted) {
+		return ""
+	}
+	b := s.Formatted[off : off+16]
+
+	if allBytes(b, 0x00) || allBytes(b, 0xFF) {
+		return ""
+	}
+
+	return fmt.Sprintf("%08X-%04X-%04X-%X-%X",
+		binary.LittleEndian.Uint32(b[0:]),
+		binary.LittleEndian.Uint16(b[4:]),
+		binary.LittleEndian.Uint16(b[6:]),
+		b[8:10],
+		b[10:16])
+}
+
+func allBytes(b []byte, v byte) bool {
+	for _, c := range b {
+		if c != v {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheSize
+// The top bit picks 1 KiB or 64 KiB granularity.
+func cacheSize(v uint32, granularityBit int) uint64 {
+	size := uint64(v &^ (1 << granularityBit))
+	if v&(1<<granularityBit) != 0 {
+		return size * 64 << 10
+	}
+	return size << 10
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// smbiosStruct
+// A raw structure: the header, the formatted area of the given length
+// with the fields set at their offsets, then the string-set.
+func smbiosStruct(typ byte, length int, fields map[int][]byte, strs ...string) []byte {
+	b := make([]byte, length)
+	b[0], b[1], b[2] = typ, byte(length), typ // handle = type
+	for off, v := range fields {
+		copy(b[off:], v)
+	}
+
+	if len(strs) == 0 {
+		return append(b, 0, 0)
+	}
+	for _, s := range strs {
+		b = append(append(b, s...), 0)
+	}
+	return append(b, 0)
+}
+
+// smbiosTable
+// The structures, then an end-of-table one.
+func smbiosTable(structs ...[]byte) []byte {
+	return bytes.Join(append(structs, smbiosStruct(127, 4, nil)), nil)
+}
+
+func TestParseSMBIOS(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		table []byte
+		want  SMBIOS
+	}{
+		{
+			name: "BIOS",
+			table: smbiosTable(smbiosStruct(0, 0x18,
+				map[int][]byte{0x04: {1}, 0x05: {2}, 0x08: {3}, 0x14: {1}, 0x15: {21}},
+				"Dell Inc.", "1.21.0", "07/11/2024")),
+			want: SMBIOS{BIOS: []SMBIOSBIOS{{
+				Vendor: "Dell Inc.", Version: "1.21.0", ReleaseDate: "07/11/2024",
+				MajorRelease: 1, MinorRelease: 21,
+			}}},
+		},
+		{
+			name: "system",
+			table: smbiosTable(smbiosStruct(1, 0x1B, map[int][]byte{
+				0x04: {1}, 0x05: {2}, 0x07: {3}, 0x19: {4}, 0x1A: {5},
+				0x08: {0x44, 0x45, 0x4C, 0x4C, 0x35, 0x00, 0x10, 0x4E,
+					0x80, 0x4C, 0xB7, 0xC0, 0x4F, 0x59, 0x35, 0x32},
+			}, "Dell Inc.", "Latitude 7420", "5GZN9K3", "0A41", "Latitude")),
+			want: SMBIOS{Systems: []SMBIOSSystem{{
+				Manufacturer: "Dell Inc.", ProductName: "Latitude 7420", SerialNumber: "5GZN9K3",
+				UUID: "4C4C4544-0035-4E10-804C-B7C04F593532", SKU: "0A41", Family: "Latitude",
+			}}},
+		},
+		{
+			// SMBIOS 2.0: no UUID, SKU nor family, and an unset UUID reads as none.
+			name: "system 2.0",
+			table: smbiosTable(
+				smbiosStruct(1, 0x08, map[int][]byte{0x04: {1}}, "OEM"),
+				smbiosStruct(1, 0x19, map[int][]byte{0x08: []byte(strings.Repeat("\xFF", 16))})),
+			want: SMBIOS{Systems: []SMBIOSSystem{{Manufacturer: "OEM"}, {}}},
+		},
+		{
+			name: "baseboard",
+			table: smbiosTable(smbiosStruct(2, 0x0F,
+				map[int][]byte{0x04: {1}, 0x05: {2}, 0x06: {3}, 0x07: {4}, 0x08: {0}},
+				"Dell Inc.", "0M3X8F", "A00", " /5GZN9K3/CNCMK0018U0123/ ")),
+			want: SMBIOS{Baseboards: []SMBIOSBaseboard{{
+				Manufacturer: "Dell Inc.", Product: "0M3X8F", Version: "A00",
+				SerialNumber: "/5GZN9K3/CNCMK0018U0123/", // trimmed
+			}}},
+		},
+		{
+			name: "chassis",
+			table: smbiosTable(smbiosStruct(3, 0x09,
+				map[int][]byte{0x04: {1}, 0x05: {0x80 | 10}, 0x07: {2}},
+				"Dell Inc.", "5GZN9K3")),
+			want: SMBIOS{Chassis: []SMBIOSChassis{{
+				Manufacturer: "Dell Inc.", Type: 10, SerialNumber: "5GZN9K3", // lock bit dropped
+			}}},
+		},
+		{
+			name:  "processor and caches",
+			table: smbiosProcessorTable(),
+			want: SMBIOS{
+				Processors: []SMBIOSProcessor{{
+					SocketDesignation: "CPU 0", Manufacturer: "Intel(R) Corporation",
+					Version:  "11th Gen Intel(R) Core(TM) i7-1185G7 @ 3.00GHz",
+					MaxSpeed: 4800, CurrentSpeed: 3000, Populated: true,
+					L1Handle: 0x70, L2Handle: 0x71, L3Handle: 0x72,
+					CoreCount: 4, ThreadCount: 8,
+				}},
+				Caches: []SMBIOSCache{
+					{Handle: 0x70, SocketDesignation: "L1 Cache", Level: 1, InstalledSize: 320 << 10},
+					{Handle: 0x71, SocketDesignation: "L2 Cache", Level: 2, InstalledSize: 5 << 20},  // 64 KiB granularity
+					{Handle: 0x72, SocketDesignation: "L3 Cache", Level: 3, InstalledSize: 12 << 20}, // in the 3.1 dword
+				},
+			},
+		},
+		{
+			name: "power supplies",
+			table: smbiosTable(
+				smbiosStruct(39, 0x16, map[int][]byte{
+					0x05: {1}, 0x06: {2}, 0x07: {3}, 0x08: {4}, 0x0A: {5}, 0x0C: {0x26, 0x02},
+				}, "PSU 1", "DPS-550AB", "Delta", "DTH1234567", "0J1CW6"),
+				// Unknown capacity
+				smbiosStruct(39, 0x16, map[int][]byte{0x05: {1}, 0x0C: {0x00, 0x80}}, "PSU 2")),
+			want: SMBIOS{PowerSupplies: []SMBIOSPowerSupply{
+				{
+					Location: "PSU 1", DeviceName: "DPS-550AB", Manufacturer: "Delta",
+					SerialNumber: "DTH1234567", ModelPartNumber: "0J1CW6", MaxPowerCapacity: 550,
+				},
+				{Location: "PSU 2"},
+			}},
+		},
+		{
+			name: "memory devices",
+			table: smbiosTable(
+				// 16 GiB DDR4-3200, in MiB
+				smbiosStruct(17, 0x28, map[int][]byte{
+					0x04: {0x10, 0x00}, 0x0C: {0x00, 0x40}, 0x10: {1}, 0x11: {2}, 0x12: {0x1A},
+					0x15: {0x80, 0x0C}, 0x17: {3}, 0x18: {4}, 0x1A: {5},
+				}, "DIMM A", "BANK 0", "Samsung", "36A1B2C3", "M471A2K43EB1-CWE  "),
+				// An empty slot
+				smbiosStruct(17, 0x28, map[int][]byte{0x10: {1}}, "DIMM B"),
+				// 512 KiB
+				smbiosStruct(17, 0x15, map[int][]byte{0x0C: {0x00, 0x82}}),
+				// 128 GiB DDR5-8400, both past their word fields
+				smbiosStruct(17, 0x5C, map[int][]byte{
+					0x0C: {0xFF, 0x7F}, 0x1C: {0x00, 0x00, 0x02, 0x00},
+					0x15: {0xFF, 0xFF}, 0x54: {0xD0, 0x20, 0x00, 0x00},
+				}),
+				// Unknown size
+				smbiosStruct(17, 0x15, map[int][]byte{0x0C: {0xFF, 0xFF}})),
+			want: SMBIOS{MemoryDevices: []SMBIOSMemoryDevice{
+				{
+					ArrayHandle: 0x10, Size: 16 << 30, DeviceLocator: "DIMM A", BankLocator: "BANK 0",
+					MemoryType: 0x1A, Speed: 3200, Manufacturer: "Samsung", SerialNumber: "36A1B2C3",
+					PartNumber: "M471A2K43EB1-CWE",
+				},
+				{DeviceLocator: "DIMM B"},
+				{Size: 512 << 10},
+				{Size: 128 << 30, Speed: 8400},
+				{},
+			}},
+		},
+		{
+			// Strings are 1-based, 0 is none and past the set is none, too.
+			name: "string index",
+			table: smbiosTable(smbiosStruct(2, 0x09,
+				map[int][]byte{0x04: {0}, 0x05: {1}, 0x06: {2}, 0x07: {3}, 0x08: {0xFF}},
+				"Board", "1.0")),
+			want: SMBIOS{Baseboards: []SMBIOSBaseboard{{Product: "Board", Version: "1.0"}}},
+		},
+		{
+			// No strings is a double NUL right after the formatted area,
+			// whose own zero bytes don't end anything.
+			name: "string-set terminator",
+			table: smbiosTable(
+				smbiosStruct(3, 0x09, map[int][]byte{0x05: {3}}),
+				smbiosStruct(0, 0x18, map[int][]byte{0x04: {1}, 0x14: {2}}, "AMI")),
+			want: SMBIOS{
+				Chassis: []SMBIOSChassis{{Type: 3}},
+				BIOS:    []SMBIOSBIOS{{Vendor: "AMI", MajorRelease: 2}},
+			},
+		},
+		{
+			name: "end-of-table",
+			table: append(smbiosTable(smbiosStruct(2, 0x08, map[int][]byte{0x04: {1}}, "Board")),
+				smbiosStruct(2, 0x08, map[int][]byte{0x04: {1}}, "After the end")...),
+			want: SMBIOS{Baseboards: []SMBIOSBaseboard{{Manufacturer: "Board"}}},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseSMBIOS(c.table)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got.Structures = nil
+			if !reflect.DeepEqual(*got, c.want) {
+				t.Errorf("got  %+v\nwant %+v", *got, c.want)
+			}
+		})
+	}
+}
+
+// smbiosProcessorTable
+// A processor with its L1, L2 and L3 caches, each size encoded another way.
+func smbiosProcessorTable() []byte {
+	return smbiosTable(
+		smbiosStruct(4, 0x28, map[int][]byte{
+			0x04: {1}, 0x07: {2}, 0x10: {3}, 0x14: {0xC0, 0x12}, 0x16: {0xB8, 0x0B}, 0x18: {0x41},
+			0x1A: {0x70, 0x00}, 0x1C: {0x71, 0x00}, 0x1E: {0x72, 0x00}, 0x23: {4}, 0x25: {8},
+		}, "CPU 0", "Intel(R) Corporation", "11th Gen Intel(R) Core(TM) i7-1185G7 @ 3.00GHz"),
+		smbiosStruct(7, 0x13, map[int][]byte{
+			0x02: {0x70, 0x00}, 0x04: {1}, 0x05: {0x80, 0x01}, 0x09: {0x40, 0x01},
+		}, "L1 Cache"),
+		smbiosStruct(7, 0x13, map[int][]byte{
+			0x02: {0x71, 0x00}, 0x04: {1}, 0x05: {0x81, 0x01}, 0x09: {0x50, 0x80},
+		}, "L2 Cache"),
+		smbiosStruct(7, 0x1B, map[int][]byte{
+			0x02: {0x72, 0x00}, 0x04: {1}, 0x05: {0x82, 0x01}, 0x09: {0xFF, 0xFF}, 0x17: {0xC0, 0x00, 0x00, 0x80},
+		}, "L3 Cache"))
+}
+
+// Processors refer to their caches by handle.
+func TestSMBIOSCache(t *testing.T) {
+	table, err := ParseSMBIOS(smbiosProcessorTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := table.Processors[0]
+	for handle, want := range map[uint16]uint8{p.L1Handle: 1, p.L2Handle: 2, p.L3Handle: 3} {
+		if c, ok := table.Cache(handle); !ok || c.Level != want {
+			t.Errorf("Cache(%#x) = %+v, %v, want level %d", handle, c, ok, want)
+		}
+	}
+	for _, handle := range []uint16{0xFFFF, 0x73} {
+		if c, ok := table.Cache(handle); ok {
+			t.Errorf("Cache(%#x) = %+v, want none", handle, c)
+		}
+	}
+
+	// Sizes only fill in for what /sys didn't have.
+	cpus := CPUs{{L2CacheSize: 1280}, {}}
+	cpus.collectSMBIOS(table)
+	if cpus[0].SocketDesignation != "CPU 0" || cpus[0].L2CacheSize != 1280 || cpus[0].L3CacheSize != 12<<10 {
+		t.Errorf("CPU 0 = %+v, want the socket, its own L2 and a 12 MiB L3", cpus[0])
+	}
+	if cpus[1] != (CPU{}) {
+		t.Errorf("CPU 1 = %+v, without a processor structure", cpus[1])
+	}
+}
+
+func TestParseSMBIOSErrors(t *testing.T) {
+	board := smbiosStruct(2, 0x08, map[int][]byte{0x04: {1}}, "Board")
+
+	for name, table := range map[string][]byte{
+		"empty":                {},
+		"short length":         {2, 0x02, 0, 0, 0, 0},
+		"truncated":            board[:6],
+		"unterminated strings": board[:len(board)-1],
+	} {
+		if _, err := ParseSMBIOS(table); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+
+	// A RawSMBIOSData header announcing more than there is
+	raw := append([]byte{0, 3, 4, 0, 0xFF, 0, 0, 0}, board...)
+	if _, err := ParseRawSMBIOSData(raw); err == nil {
+		t.Error("truncated RawSMBIOSData: no error")
+	}
+	raw[4] = byte(len(board))
+	if got, err := ParseRawSMBIOSData(raw); err != nil || got.Baseboards[0].Manufacturer != "Board" {
+		t.Errorf("RawSMBIOSData = %+v, %v", got, err)
+	}
+}
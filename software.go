@@ -0,0 +1,177 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type InstalledSoftware []Program
+
+// Program
+// An entry of Apps & features, i.e., of an Uninstall registry key.
+type Program struct {
+	Name        string
+	Version     string
+	Publisher   string
+	InstallDate string // YYYY-MM-DD, when the installer tells
+	Size        ProgramSize
+}
+
+// ProgramSize
+// EstimatedSize, in KiB, as guessed by the installer.
+type ProgramSize uint64
+
+var _ = RegisterSection(Section{
+	Name: "InstalledSoftware", Key: "software",
+	Aliases: []string{"installedsoftware", "programs", "apps"}, Order: 110,
+	New: func() any { return &InstalledSoftware{} },
+	Collect: func(ctx context.Context, src Sources, _ *Specs, node any) error {
+		return node.(*InstalledSoftware).collect(ctx, src.Registry)
+	},
+})
+
+// uninstallKeys
+// Machine-wide 64-bit and 32-bit programs, then the current user's own.
+var uninstallKeys = []string{
+	`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`,
+	`HKLM\SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall`,
+	`HKCU\Software\Microsoft\Windows\CurrentVersion\Uninstall`,
+}
+
+// collect
+// Programs listed under any of uninstallKeys, by name,
+// each name and version once, whichever key it's found under.
+// Only Windows has them, elsewhere the section is empty.
+func (p *InstalledSoftware) collect(ctx context.Context, r RegistrySource) error {
+	if r == nil {
+		return nil
+	}
+
+	seen := map[string]int{}
+	var errs []error
+
+	for _, root := range uninstallKeys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		names, err := subKeyNames(r, root)
+		if err != nil {
+			// No WOW6432Node on 32-bit Windows, no per-user programs at all, etc.
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, name := range names {
+			v, ok := readProgram(r, root+`\`+name)
+			if !ok {
+				continue
+			}
+
+			id := strings.ToLower(v.Name + "\x00" + v.Version)
+			if i, dup := seen[id]; dup {
+				(*p)[i].fill(v)
+				continue
+			}
+			seen[id] = len(*p)
+			*p = append(*p, v)
+		}
+	}
+
+	// Handle empty string
+	for i := range *p {
+		for _, f := range []*string{&(*p)[i].Version, &(*p)[i].Publisher, &(*p)[i].InstallDate} {
+			if *f == "" {
+				*f = "N/A"
+			}
+		}
+	}
+
+	slices.SortStableFunc(*p, func(a, b Program) int {
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	// Not even the machine-wide key
+	if len(errs) == len(uninstallKeys) {
+		return errs[0]
+	}
+	return nil
+}
+
+func subKeyNames(r RegistrySource, path string) ([]string, error) {
+	reg, err := r.OpenKey(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(reg RegistryKey) {
+		err := reg.Close()
+		if err != nil {
+			return
+		}
+	}(reg)
+
+	names, err := reg.ReadSubKeyNames()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return names, nil
+}
+
+// readProgram
+// A program as Apps & features lists it, so not without a name,
+// nor system components and updates, which belong to another program.
+func readProgram(r RegistrySource, path string) (v Program, ok bool) {
+	reg, err := r.OpenKey(path)
+	if err != nil {
+		return v, false
+	}
+	defer func(reg RegistryKey) {
+		err := reg.Close()
+		if err != nil {
+			return
+		}
+	}(reg)
+
+	v.Name, _ = reg.GetStringValue("DisplayName")
+	v.Name = strings.TrimSpace(v.Name)
+	if v.Name == "" {
+		return v, false
+	}
+	if n, _ := reg.GetIntegerValue("SystemComponent"); n == 1 {
+		return v, false
+	}
+	if parent, _ := reg.GetStringValue("ParentKeyName"); parent != "" {
+		return v, false
+	}
+	switch t, _ := reg.GetStringValue("ReleaseType"); t {
+	case "Update", "Hotfix", "Security Update":
+		return v, false
+	}
+
+	v.Version, _ = reg.GetStringValue("DisplayVersion")
+	v.Publisher, _ = reg.GetStringValue("Publisher")
+	v.Version, v.Publisher = strings.TrimSpace(v.Version), strings.TrimSpace(v.Publisher)
+
+	// Mostly YYYYMMDD, but installers write whatever they like.
+	v.InstallDate, _ = reg.GetStringValue("InstallDate")
+	if t, err := time.Parse("20060102", v.InstallDate); err == nil {
+		v.InstallDate = t.Format(time.DateOnly)
+	}
+
+	size, _ := reg.GetIntegerValue("EstimatedSize")
+	v.Size = ProgramSize(size)
+
+	return v, true
+}
+
+// fill
+// Whatever p is missing, from another entry of the same program.
+func (p *Program) fill(v Program) {
+	p.Publisher = cmp.Or(p.Publisher, v.Publisher)
+	p.InstallDate = cmp.Or(p.InstallDate, v.InstallDate)
+	p.Size = cmp.Or(p.Size, v.Size)
+}
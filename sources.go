@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Sources
+// The backends the collectors read from.
+// Live backends come from DefaultSources,
+// recorded fixtures from ReplaySources.
+//
+// On Windows, WMI and Registry are used.
+// Elsewhere, SysFS is set to the root filesystem instead,
+// and every section is read from /proc, /sys and friends.
+//
+// Firmware, when set, provides the SMBIOS table,
+// which is preferred over both for what it covers.
+type Sources struct {
+	WMI      Querier
+	Registry RegistrySource
+	SysFS    fs.FS
+	Firmware FirmwareSource
+
+	smbios *SMBIOS // parsed once per Collect
+}
+
+// Fixture names, next to the WMI ones.
+const (
+	regFixture    = "registry.reg"
+	sysfsFixture  = "sysfs"
+	smbiosFixture = "smbios.bin"
+)
+
+// ReplaySources
+// Read from the fixtures in dir, as written by RecordSources.
+// A sysfs directory replays a Linux host,
+// otherwise WMI and the optional registry and SMBIOS fixtures are used.
+func ReplaySources(dir string) (Sources, error) {
+	if fi, err := os.Stat(filepath.Join(dir, sysfsFixture)); err == nil && fi.IsDir() {
+		return Sources{SysFS: os.DirFS(filepath.Join(dir, sysfsFixture))}, nil
+	}
+
+	reg, err := LoadRegFile(filepath.Join(dir, regFixture))
+	if errors.Is(err, fs.ErrNotExist) {
+		reg, err = NewRegFile(), nil
+	}
+	if err != nil {
+		return Sources{}, err
+	}
+
+	src := Sources{
+		WMI:      ReplayQuerier{Dir: dir},
+		Registry: reg,
+	}
+	if _, err := os.Stat(filepath.Join(dir, smbiosFixture)); err == nil {
+		src.Firmware = FirmwareFile{Path: filepath.Join(dir, smbiosFixture)}
+	}
+
+	return src, nil
+}
+
+// RecordSources
+// Pass through to src, dumping whatever is read into dir.
+// WMI fixtures are written as they come,
+// the registry fixture only when save is called.
+func RecordSources(src Sources, dir string) (rec Sources, save func() error) {
+	if src.SysFS != nil {
+		rec = Sources{
+			SysFS: RecordingFS{FS: src.SysFS, Dir: filepath.Join(dir, sysfsFixture)},
+		}
+		return rec, func() error { return nil }
+	}
+
+	reg := NewRegFile()
+
+	rec = Sources{
+		WMI:      RecordingQuerier{Querier: src.WMI, Dir: dir},
+		Registry: RecordingRegistry{Registry: src.Registry, File: reg},
+	}
+	if src.Firmware != nil {
+		rec.Firmware = RecordingFirmware{
+			Firmware: src.Firmware,
+			Path:     filepath.Join(dir, smbiosFixture),
+		}
+	}
+	save = func() error {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		return reg.Save(filepath.Join(dir, regFixture))
+	}
+
+	return rec, save
+}
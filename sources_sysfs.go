@@ -0,0 +1,15 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+)
+
+// DefaultSources
+// The live root filesystem.
+func DefaultSources() Sources {
+	return Sources{
+		SysFS: os.DirFS("/"),
+	}
+}
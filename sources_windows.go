@@ -0,0 +1,13 @@
+//go:build windows
+
+package main
+
+// DefaultSources
+// The live WMI service, registry and firmware tables.
+func DefaultSources() Sources {
+	return Sources{
+		WMI:      WMI{},
+		Registry: WinRegistry{},
+		Firmware: WinFirmware{},
+	}
+}
@@ -1,5 +1,3 @@
-//go:build windows
-
 package main
 
 import (
@@ -19,15 +17,24 @@ import (
 //}
 
 func (c CPUMaxClockSpeed) String() string {
+	if OutputUnits != UnitsDefault {
+		return clockSpeedUnits.text(uint64(c))
+	}
 	return fmt.Sprintf("%.3f", float64(c)/1e3) // 3 decimal digits
 }
 
 // WMI returns L2 and L3 cache size in KiB
 
 func (c L2CacheSize) String() string {
+	if OutputUnits != UnitsDefault {
+		return cacheSizeUnits.text(uint64(c))
+	}
 	return fmt.Sprintf("%d", int64(c)/units.KiB)
 }
 func (c L3CacheSize) String() string {
+	if OutputUnits != UnitsDefault {
+		return cacheSizeUnits.text(uint64(c))
+	}
 	return fmt.Sprintf("%d", int64(c)/units.KiB)
 }
 
@@ -75,6 +82,9 @@ func (d DIMMType) String() string {
 //}
 
 func (d DIMMCapacity) String() string {
+	if OutputUnits != UnitsDefault {
+		return memorySizeUnits.text(uint64(d))
+	}
 	return fmt.Sprintf("%d", d/units.GiB)
 }
 
@@ -107,6 +117,59 @@ func (d DIMMTypeDetail) String() (s string) {
 }
 //*/
 
+////////////////////////////////////////////////////////////////////////////////
+// System
+////////////////////////////////////////////////////////////////////////////////
+
+// See: https://www.dmtf.org/sites/default/files/standards/documents/DSP0134_3.4.0.pdf
+// Table 17
+func (c ChassisType) String() string {
+	types := []string{
+		"unknown", // 0 is not defined, i.e., not collected.
+		"Other",
+		"unknown",
+		"Desktop",
+		"Low Profile Desktop",
+		"Pizza Box",
+		"Mini Tower",
+		"Tower",
+		"Portable",
+		"Laptop",
+		"Notebook",
+		"Hand Held",
+		"Docking Station",
+		"All in One",
+		"Sub Notebook",
+		"Space-saving",
+		"Lunch Box",
+		"Main Server Chassis",
+		"Expansion Chassis",
+		"SubChassis",
+		"Bus Expansion Chassis",
+		"Peripheral Chassis",
+		"RAID Chassis",
+		"Rack Mount Chassis",
+		"Sealed-case PC",
+		"Multi-system chassis",
+		"Compact PCI",
+		"Advanced TCA",
+		"Blade",
+		"Blade Enclosure",
+		"Tablet",
+		"Convertible",
+		"Detachable",
+		"IoT Gateway",
+		"Embedded PC",
+		"Mini PC",
+		"Stick PC",
+	}
+
+	if int(c) < len(types) {
+		return types[c]
+	}
+	return "unknown"
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Disk
 ////////////////////////////////////////////////////////////////////////////////
@@ -116,14 +179,33 @@ func (d DIMMTypeDetail) String() (s string) {
 //}
 
 func (d DiskSize) String() string {
+	if OutputUnits != UnitsDefault {
+		return diskSizeUnits.text(uint64(d))
+	}
 	return fmt.Sprintf("%d", d/units.GB)
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// Installed software
+////////////////////////////////////////////////////////////////////////////////
+
+func (p ProgramSize) String() string {
+	if OutputUnits != UnitsDefault {
+		return programSizeUnits.text(uint64(p))
+	}
+	return fmt.Sprintf("%d", int64(p)*units.KiB/units.MiB)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Windows
 ////////////////////////////////////////////////////////////////////////////////
 
 func (d WinInstallDate) String() string {
+	// CIM datetime, e.g., 20240131235959.000000+420
+	if len(d) < 25 {
+		return "N/A"
+	}
+
 	datetime := string(d[:14])
 	offset := string(d[22:])
 
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// RecordingFS
+// Passes reads through to FS and copies whatever is read into Dir,
+// so the tree can be replayed later with os.DirFS(Dir).
+// Listed subdirectories (and sysfs symlinks, which point to directories)
+// are kept even if empty, which is enough for existence checks on replay.
+type RecordingFS struct {
+	FS  fs.FS
+	Dir string
+}
+
+func (r RecordingFS) Open(name string) (fs.File, error) {
+	return r.FS.Open(name)
+}
+
+func (r RecordingFS) ReadFile(name string) ([]byte, error) {
+	data, err := fs.ReadFile(r.FS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(r.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (r RecordingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(r.FS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() && e.Type()&fs.ModeSymlink == 0 {
+			continue
+		}
+		dir := filepath.Join(r.Dir, filepath.FromSlash(name), e.Name())
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+func (r RecordingFS) Stat(name string) (fs.FileInfo, error) {
+	fi, err := fs.Stat(r.FS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		dir := filepath.Join(r.Dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	return fi, nil
+}
@@ -1,10 +1,10 @@
-//go:build windows
-
 package main
 
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
 )
 
 // Table
@@ -15,6 +15,11 @@ import (
 // Normal CSV creation, its column delimiter and quote character
 // should be handled by the caller.
 func (s *Specs) Table(data any, pretty bool, a int, label ...string) (z [][]string) {
+	// The report itself, section by section.
+	if sp, ok := data.(*Specs); ok {
+		data = sp.document(false)
+	}
+
 	t := reflect.TypeOf(data)
 	v := reflect.ValueOf(data)
 
@@ -23,11 +28,6 @@ func (s *Specs) Table(data any, pretty bool, a int, label ...string) (z [][]stri
 		l = label[0]
 	}
 
-	b, m := a, l // recursion keepers
-
-	const w = 2  // indentation width per level.
-	const x = "" // indentation helper string.
-
 	if t.Kind() == reflect.Ptr {
 		t, v = t.Elem(), v.Elem()
 	}
@@ -37,85 +37,100 @@ func (s *Specs) Table(data any, pretty bool, a int, label ...string) (z [][]stri
 	}
 
 	for i := range t.NumField() {
-		key, val := t.Field(i), v.Field(i)
+		z = append(z, s.tableField(t.Field(i), v.Field(i), pretty, a, l)...)
+	}
 
-		switch val.Kind() {
-		case reflect.Struct:
-			switch {
-			case pretty:
-				a = b
-				z = append(z, []string{fmt.Sprintf("%-*s%s", a, x, key.Name), ""})
+	return z
+}
 
-				a += w // a = b+w works, too; probably needs further analysis.
-			default:
-				a, l = 0, key.Name+" "
-			}
+// tableField
+// Table rows of a single field, see Table.
+func (s *Specs) tableField(key reflect.StructField, val reflect.Value, pretty bool, a int, l string) (z [][]string) {
+	b, m := a, l // recursion keepers
 
-			// mind the ellipsis ...
-			z = append(z, s.Table(val.Interface(), pretty, a, l)...)
+	const w = 2  // indentation width per level.
+	const x = "" // indentation helper string.
 
-		case reflect.Slice:
-			if pretty {
-				l = key.Name
-				a = b
-				z = append(z, []string{fmt.Sprintf("%-*s%s", a, x, l), ""})
-			}
+	name := tableKey(key)
 
-			for j := range val.Len() {
-				l = fmt.Sprintf("%s%d", val.Index(j).Type().Name(), j)
+	// Sections left out of collection
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return z
+		}
+		val = val.Elem()
+	}
 
-				switch {
-				case pretty:
-					a = b + w
-					z = append(z, []string{fmt.Sprintf("%-*s%s", a, x, l), ""})
+	switch val.Kind() {
+	case reflect.Struct:
+		switch {
+		case pretty:
+			z = append(z, []string{fmt.Sprintf("%-*s%s", a, x, name), ""})
+
+			a += w
+		default:
+			a, l = 0, name+" "
+		}
 
-					a, l = b+2*w, ""
-				default:
-					a, l = b, l+" "
-				}
+		// mind the ellipsis ...
+		z = append(z, s.Table(val.Interface(), pretty, a, l)...)
 
-				// mind the ellipsis ...
-				z = append(z, s.Table(val.Index(j).Interface(), pretty, a, l)...)
-			}
+	case reflect.Slice:
+		// Nothing to report, e.g., no diagnostics.
+		if val.Len() == 0 && strings.Contains(key.Tag.Get("json"), "omitempty") {
+			return z
+		}
 
-		default:
-			r := val.Interface()
+		if pretty {
+			z = append(z, []string{fmt.Sprintf("%-*s%s", a, x, name), ""})
+		}
+
+		for j := range val.Len() {
+			l = fmt.Sprintf("%s%d", val.Index(j).Type().Name(), j)
+			if !pretty && componentLabel.MatchString(m) {
+				l = m + l
+			}
 
 			switch {
 			case pretty:
-				l = s.mapKey(key.Name)
+				a = b + w
+				z = append(z, []string{fmt.Sprintf("%-*s%s", a, x, l), ""})
+
+				a, l = b+2*w, ""
 			default:
-				l = m + s.mapKey(key.Name)
+				a, l = b, l+" "
 			}
 
-			z = append(z, []string{
-				fmt.Sprintf("%-*s%s", a, x, l),
-				fmt.Sprintf("%v", r),
-			})
+			// mind the ellipsis ...
+			z = append(z, s.Table(val.Index(j).Interface(), pretty, a, l)...)
+		}
+
+	default:
+		if !pretty {
+			name = m + name
 		}
+
+		z = append(z, []string{
+			fmt.Sprintf("%-*s%s", a, x, name),
+			fmt.Sprintf("%v", val.Interface()),
+		})
 	}
 
 	return z
 }
 
-// mapKey
-// Translate key names to be more intuitive.
-func (*Specs) mapKey(f string) string {
-	// This map is unsafe.
-	// Two children of different parents may have the same field name.
-	m := map[string]string{
-		"CSName":               "DeviceName",
-		"Caption":              "Edition",
-		"SocketDesignation":    "SocketType",
-		"NumberOfCores":        "TotalCore",
-		"ThreadCount":          "TotalThread",
-		"SMBIOSMemoryType":     "Type",
-		"AdapterCompatibility": "Vendor",
-		"AdapterDACType":       "Type",
-	}
-
-	if g, ok := m[f]; ok {
-		return g
+// componentLabel
+// The label of a component, e.g., "Disk0 ", rather than a section's,
+// so the components of a component are told apart, e.g., "Disk0 Volume0 ".
+var componentLabel = regexp.MustCompile(`\d+ $`)
+
+// tableKey
+// The field name as in JSON, e.g., DeviceName for CSName,
+// so every output format agrees.
+func tableKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
 	}
-	return f
+	return name
 }
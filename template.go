@@ -0,0 +1,164 @@
+//go:build cli
+
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// builtinTemplates
+// Shipped with the CLI, e.g., -template label, see ParseReportTemplate.
+//
+//go:embed assets/templates/*.tmpl
+var builtinTemplates embed.FS
+
+// BuiltinTemplates
+// Names of the built-in templates, e.g., label.
+func BuiltinTemplates() (names []string) {
+	entries, _ := builtinTemplates.ReadDir("assets/templates")
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".tmpl"))
+	}
+	return names
+}
+
+// ParseReportTemplate
+// A text/template of the report, see Specs.templateData,
+// either built in by name, e.g., label, or read from a file,
+// with templateFuncs at hand.
+func ParseReportTemplate(name string) (*template.Template, error) {
+	var text []byte
+	var err error
+
+	if slices.Contains(BuiltinTemplates(), name) {
+		text, err = builtinTemplates.ReadFile(path.Join("assets/templates", name+".tmpl"))
+	} else {
+		text, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return template.New(name).Funcs(templateFuncs).Parse(string(text))
+}
+
+// TextTemplate
+// The report rendered through t, see ParseReportTemplate.
+func (s *Specs) TextTemplate(t *template.Template) (string, error) {
+	var buf strings.Builder
+	if err := t.Execute(&buf, s.templateData(time.Now())); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// templateFuncs
+// The String formatters, for values as stored, e.g., {{dimmType 26}},
+// sizes and speeds in given units, e.g., {{units "si" .Memory.TotalSize}}
+// or {{human .Memory.TotalSize}}, whatever -units says,
+// whether a value was collected at all, e.g., {{if known .System.SKU}},
+// and a few string helpers.
+var templateFuncs = template.FuncMap{
+	"dimmType":    func(v any) (string, error) { return formatUint[DIMMType](v) },
+	"chassisType": func(v any) (string, error) { return formatUint[ChassisType](v) },
+	"memorySize":  func(v any) (string, error) { return formatUint[DIMMCapacity](v) },
+	"diskSize":    func(v any) (string, error) { return formatUint[DiskSize](v) },
+	"clockSpeed":  func(v any) (string, error) { return formatUint[CPUMaxClockSpeed](v) },
+	"installDate": func(v any) string {
+		if d, ok := v.(WinInstallDate); ok {
+			return d.String()
+		}
+		return WinInstallDate(fmt.Sprint(v)).String()
+	},
+
+	"units": inUnits,
+	"human": func(v any) (string, error) { return inUnits(string(UnitsHuman), v) },
+	"known": func(v any) bool {
+		rv := reflect.ValueOf(v)
+		return rv.IsValid() && !rv.IsZero() && known(fmt.Sprint(v))
+	},
+
+	"join": func(sep string, v any) string {
+		rv := reflect.Indirect(reflect.ValueOf(v))
+		if rv.Kind() != reflect.Slice {
+			return fmt.Sprint(v)
+		}
+		items := make([]string, rv.Len())
+		for i := range rv.Len() {
+			items[i] = fmt.Sprint(rv.Index(i).Interface())
+		}
+		return strings.Join(items, sep)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"pad":   func(n int, v any) string { return fmt.Sprintf("%-*v", n, v) },
+}
+
+// formatUint
+// v, any number, as T would write it.
+func formatUint[T interface {
+	~uint64
+	fmt.Stringer
+}](v any) (string, error) {
+	n, err := toUint64(v)
+	if err != nil {
+		return "", err
+	}
+	return T(n).String(), nil
+}
+
+func toUint64(v any) (uint64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() >= 0 {
+			return uint64(rv.Int()), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if rv.Float() >= 0 {
+			return uint64(rv.Float()), nil
+		}
+	}
+	return 0, fmt.Errorf("expecting a positive number, got %v", v)
+}
+
+// valueUnits
+// The quantity of each size and speed, see inUnits.
+var valueUnits = map[reflect.Type]quantity{
+	reflect.TypeFor[CPUMaxClockSpeed](): clockSpeedUnits,
+	reflect.TypeFor[L2CacheSize]():      cacheSizeUnits,
+	reflect.TypeFor[L3CacheSize]():      cacheSizeUnits,
+	reflect.TypeFor[DIMMCapacity]():     memorySizeUnits,
+	reflect.TypeFor[DiskSize]():         diskSizeUnits,
+	reflect.TypeFor[ProgramSize]():      programSizeUnits,
+}
+
+// inUnits
+// A size or speed of the report as written in u, see ParseUnits,
+// whatever -units says. Values as is, e.g., {{.Memory.TotalSize}}, go by it.
+func inUnits(u string, v any) (string, error) {
+	units, err := ParseUnits(u)
+	if err == nil && units == UnitsDefault {
+		err = fmt.Errorf("missing units, expecting raw, si, iec or human")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	q, ok := valueUnits[reflect.TypeOf(v)]
+	if !ok {
+		return "", fmt.Errorf("%T is neither a size nor a speed", v)
+	}
+	return q.textIn(reflect.ValueOf(v).Uint(), units), nil
+}
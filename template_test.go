@@ -0,0 +1,98 @@
+//go:build cli
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/templates/*.golden")
+
+// templateSpecs
+// The replay fixture, with a set user, since that one isn't replayed.
+func templateSpecs(t *testing.T) *Specs {
+	t.Helper()
+
+	src, err := ReplaySources("testdata/replay/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Specs{}
+	if err := s.Collect(context.Background(), src, CollectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	*s.node("user").(*CurrentUser) = CurrentUser{
+		Username: `CONTOSO\jdoe`, Fullname: "Jane Doe", SID: "S-1-5-21-1004336348-1177238915-682003330-1104",
+	}
+	return s
+}
+
+// renderTemplate
+// s through the built-in template name, at a set time.
+func renderTemplate(t *testing.T, s *Specs, name string) string {
+	t.Helper()
+
+	tmpl, err := ParseReportTemplate(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, s.templateData(time.Date(2025, 6, 2, 9, 30, 0, 0, time.UTC))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// The built-in templates render the replay fixture
+// as in testdata/templates, go test -tags cli -update rewrites them.
+func TestBuiltinTemplates(t *testing.T) {
+	s := templateSpecs(t)
+
+	for _, name := range BuiltinTemplates() {
+		t.Run(name, func(t *testing.T) {
+			got := renderTemplate(t, s, name)
+
+			golden := filepath.Join("testdata/templates", name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// The fixture has neither a SKU, a UUID nor a maximum memory capacity,
+// which are written once known.
+func TestBuiltinTemplatesKnown(t *testing.T) {
+	s := templateSpecs(t)
+	sys := s.node("system").(*System)
+	sys.SKU, sys.UUID = "LENOVO_MT_20XW_BU_Think_FM_ThinkPad X1 Carbon Gen 9", "4C4C4544-0035-4E10-804C-B7C04F593532"
+	s.node("memory").(*Memory).MaxCapacity = 32 << 30
+
+	for name, want := range map[string][]string{
+		"label":  {", SKU LENOVO_MT_20XW", "UUID 4C4C4544-0035"},
+		"ticket": {"slots, up to 32 GiB"},
+		"wiki":   {"20XW0055GE (LENOVO_MT_20XW"},
+	} {
+		got := renderTemplate(t, s, name)
+		for _, w := range want {
+			if !strings.Contains(got, w) {
+				t.Errorf("%s: no %q in\n%s", name, w, got)
+			}
+		}
+	}
+}
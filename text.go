@@ -1,4 +1,4 @@
-//go:build windows && cli
+//go:build cli
 
 package main
 
@@ -44,51 +44,3 @@ func (s *Specs) TextFlat(delim ...string) (out string) {
 
 	return out
 }
-
-func (s *Specs) TextVCSV(delimQuote ...string) (out string) {
-	tbl := s.Table(s, false, 0)
-
-	d, q := ",", `"` // default column delimiter and quote string
-	if len(delimQuote) > 0 {
-		d = delimQuote[0]
-	}
-	if len(delimQuote) > 1 {
-		q = delimQuote[1]
-	}
-
-	for _, col := range tbl {
-		out += q + col[0] + q + d + q + col[1] + q + "\n"
-	}
-
-	return out
-}
-
-func (s *Specs) TextCSV(delimQuote ...string) (out string) {
-	tbl := s.Table(s, false, 0)
-
-	d, q := ",", `"` // default column delimiter and quote string
-	if len(delimQuote) > 0 {
-		d = delimQuote[0]
-	}
-	if len(delimQuote) > 1 {
-		q = delimQuote[1]
-	}
-
-	l := len(tbl)
-	for i := 0; i <= 1; i++ {
-		for j := range l {
-			out += q + tbl[j][i] + q
-
-			// append delimiter unless the last column
-			if j < l-1 {
-				out += d
-			}
-			// append newline to the last column
-			if j == l-1 {
-				out += "\n"
-			}
-		}
-	}
-
-	return out
-}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Units
+// How sizes and speeds are written, by every format alike.
+// The default writes whole GiB for memory, whole GB for disks,
+// whole MiB for caches and GHz for clock speeds.
+// Raw writes exact bytes and kHz, SI and IEC write fractional decimal
+// or binary multiples, e.g., 250.059350016 GB, and human writes strings,
+// e.g., "15.9 GiB".
+type Units string
+
+const (
+	UnitsDefault Units = ""
+	UnitsRaw     Units = "raw"
+	UnitsSI      Units = "si"
+	UnitsIEC     Units = "iec"
+	UnitsHuman   Units = "human"
+)
+
+// OutputUnits
+// Package-wide, since it's the Marshal and String methods that go by it.
+// Unless default, reports say which, see Specs.document.
+var OutputUnits = UnitsDefault
+
+// inputUnits
+// The units of the report being loaded, see Specs.load.
+var inputUnits = UnitsDefault
+
+func ParseUnits(s string) (Units, error) {
+	switch u := Units(strings.ToLower(s)); u {
+	case UnitsDefault, UnitsRaw, UnitsSI, UnitsIEC, UnitsHuman:
+		return u, nil
+	default:
+		return "", fmt.Errorf("unknown units %q, expecting raw, si, iec or human", s)
+	}
+}
+
+// quantity
+// A stored value's unit, and the units it's written in, all of them
+// in bytes or Hz.
+type quantity struct {
+	stored float64 // e.g., 1024 for KiB as returned by WMI
+	def    float64 // default, see Units
+	raw    float64
+	si     float64
+	iec    float64
+
+	base   string // B or Hz
+	human  Units  // SI or IEC multiples
+	digits int    // decimal digits for human
+}
+
+var (
+	clockSpeedUnits = quantity{stored: 1e6, def: 1e9, raw: 1e3, si: 1e9, iec: 1e9,
+		base: "Hz", human: UnitsSI, digits: 3}
+	cacheSizeUnits = quantity{stored: 1 << 10, def: 1 << 20, raw: 1, si: 1e6, iec: 1 << 20,
+		base: "B", human: UnitsIEC, digits: 1}
+	memorySizeUnits = quantity{stored: 1, def: 1 << 30, raw: 1, si: 1e9, iec: 1 << 30,
+		base: "B", human: UnitsIEC, digits: 1}
+	diskSizeUnits = quantity{stored: 1, def: 1e9, raw: 1, si: 1e9, iec: 1 << 30,
+		base: "B", human: UnitsSI, digits: 1}
+	programSizeUnits = quantity{stored: 1 << 10, def: 1 << 20, raw: 1, si: 1e6, iec: 1 << 20,
+		base: "B", human: UnitsIEC, digits: 1}
+)
+
+var unitPrefixes = map[Units][]string{
+	UnitsSI:  {"", "k", "M", "G", "T", "P"},
+	UnitsIEC: {"", "Ki", "Mi", "Gi", "Ti", "Pi"},
+}
+
+// value
+// v as written in OutputUnits, but the default, which every type does its own way.
+func (q quantity) value(v uint64) any {
+	return q.valueIn(v, OutputUnits)
+}
+
+func (q quantity) valueIn(v uint64, u Units) any {
+	f := float64(v) * q.stored
+
+	switch u {
+	case UnitsRaw:
+		return uint64(math.Round(f / q.raw))
+	case UnitsSI:
+		return f / q.si
+	case UnitsIEC:
+		return f / q.iec
+	default:
+		return q.humanize(f)
+	}
+}
+
+// text
+// value as in the text formats.
+func (q quantity) text(v uint64) string {
+	return q.textIn(v, OutputUnits)
+}
+
+func (q quantity) textIn(v uint64, u Units) string {
+	switch x := q.valueIn(v, u).(type) {
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func (q quantity) humanize(f float64) string {
+	step := 1000.0
+	if q.human == UnitsIEC {
+		step = 1024
+	}
+
+	prefixes := unitPrefixes[q.human]
+	i := 0
+	for f >= step && i < len(prefixes)-1 {
+		f /= step
+		i++
+	}
+
+	p := math.Pow10(q.digits)
+	f = math.Round(f*p) / p
+
+	return strconv.FormatFloat(f, 'f', -1, 64) + " " + prefixes[i] + q.base
+}
+
+// parse
+// The other way around, v as decoded from a report written in u:
+// a number, or a string with a unit, e.g., "15.9 GiB", whatever u is.
+func (q quantity) parse(v any, u Units) (uint64, error) {
+	var f float64
+
+	switch x := v.(type) {
+	case string:
+		return q.parseHuman(x)
+	case float64:
+		f = x
+	case int64:
+		f = float64(x)
+	case int:
+		f = float64(x)
+	case uint64:
+		f = float64(x)
+	default:
+		return 0, fmt.Errorf("expecting a number, got %T", v)
+	}
+
+	unit := q.def
+	switch u {
+	case UnitsRaw:
+		unit = q.raw
+	case UnitsSI:
+		unit = q.si
+	case UnitsIEC:
+		unit = q.iec
+	}
+
+	return uint64(math.Round(f * unit / q.stored)), nil
+}
+
+func (q quantity) parseHuman(s string) (uint64, error) {
+	num, unit, _ := strings.Cut(strings.TrimSpace(s), " ")
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q: %w", s, err)
+	}
+
+	for _, u := range []Units{UnitsSI, UnitsIEC} {
+		step := 1000.0
+		if u == UnitsIEC {
+			step = 1024
+		}
+
+		mult := 1.0
+		for _, p := range unitPrefixes[u] {
+			if strings.EqualFold(unit, p+q.base) {
+				return uint64(math.Round(f * mult / q.stored)), nil
+			}
+			mult *= step
+		}
+	}
+
+	return 0, fmt.Errorf("%q: unknown unit, expecting %s", s, q.base)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type Volumes []Volume
+
+// Volume
+// A lettered volume on a disk, i.e., a Win32_LogicalDisk.
+type Volume struct {
+	DriveLetter string
+	FileSystem  string
+	Label       string
+	Capacity    DiskSize
+	FreeSpace   DiskSize `diff:"volatile"`
+	BitLocker   string   // On, Off or Unknown, N/A without admin rights
+}
+
+// BitLocker lives in its own namespace, readable by admins only.
+const bitLockerNamespace = `root\CIMV2\Security\MicrosoftVolumeEncryption`
+
+var bitLockerStatus = map[uint64]string{0: "Off", 1: "On", 2: "Unknown"}
+
+// wmiDeviceID
+// The key of a WMI object path, e.g.,
+// \\PC\root\cimv2:Win32_DiskDrive.DeviceID="\\\\.\\PHYSICALDRIVE0".
+var wmiDeviceID = regexp.MustCompile(`DeviceID="((?:[^"\\]|\\.)*)"`)
+
+// collectVolumes
+// The partition style and volumes of each disk, by their Win32_DiskDrive IDs,
+// joining disks to partitions to volumes through the WMI associations.
+// Nothing here fails the disks, it's only N/A with a warning.
+func (d *Disks) collectVolumes(ctx context.Context, q Querier, ids []string) error {
+	for i := range *d {
+		(*d)[i].PartitionStyle = "N/A"
+	}
+
+	var drivesToParts, partsToVolumes []struct {
+		Antecedent string
+		Dependent  string
+	}
+	var parts []struct {
+		DeviceID string
+		Type     string
+	}
+	var volumes []struct {
+		DeviceID   string
+		FileSystem string
+		VolumeName string
+		Size       DiskSize
+		FreeSpace  DiskSize
+	}
+
+	for _, v := range []struct {
+		wql string
+		dst any
+	}{
+		{"SELECT Antecedent, Dependent FROM Win32_DiskDriveToDiskPartition", &drivesToParts},
+		{"SELECT Antecedent, Dependent FROM Win32_LogicalDiskToPartition", &partsToVolumes},
+		{"SELECT DeviceID, Type FROM Win32_DiskPartition", &parts},
+		{"SELECT DeviceID, FileSystem, VolumeName, Size, FreeSpace FROM Win32_LogicalDisk", &volumes},
+	} {
+		if err := query(ctx, q, v.wql, v.dst); err != nil {
+			return warn(fmt.Errorf("volumes: %w", err))
+		}
+	}
+
+	// e.g., GPT: Basic Data, or Installable File System on MBR
+	partTypes := map[string]string{}
+	for _, p := range parts {
+		partTypes[p.DeviceID] = p.Type
+	}
+
+	// The volumes of each partition, only lettered ones are listed.
+	volumesOf := map[string][]string{}
+	for _, a := range partsToVolumes {
+		part, vol := wmiObjectID(a.Antecedent), wmiObjectID(a.Dependent)
+		volumesOf[part] = append(volumesOf[part], vol)
+	}
+
+	byLetter := map[string]Volume{}
+	for _, v := range volumes {
+		byLetter[v.DeviceID] = Volume{
+			DriveLetter: v.DeviceID,
+			FileSystem:  v.FileSystem,
+			Label:       v.VolumeName,
+			Capacity:    v.Size,
+			FreeSpace:   v.FreeSpace,
+			BitLocker:   "N/A",
+		}
+	}
+
+	warnBitLocker := collectBitLocker(ctx, q, byLetter)
+
+	for _, a := range drivesToParts {
+		drive, part := wmiObjectID(a.Antecedent), wmiObjectID(a.Dependent)
+
+		for i, id := range ids {
+			if !strings.EqualFold(id, drive) {
+				continue
+			}
+
+			disk := &(*d)[i]
+			if t, ok := partTypes[part]; ok {
+				disk.PartitionStyle = "MBR"
+				if strings.HasPrefix(t, "GPT") {
+					disk.PartitionStyle = "GPT"
+				}
+			}
+			for _, letter := range volumesOf[part] {
+				if v, ok := byLetter[letter]; ok {
+					disk.Volumes = append(disk.Volumes, v)
+				}
+			}
+		}
+	}
+
+	// Handle empty string
+	for i := range *d {
+		for j := range (*d)[i].Volumes {
+			v := &(*d)[i].Volumes[j]
+			if v.FileSystem == "" {
+				v.FileSystem = "N/A"
+			}
+			if v.Label == "" {
+				v.Label = "N/A"
+			}
+		}
+	}
+
+	return warnBitLocker
+}
+
+// collectBitLocker
+// The protection status of each volume, by drive letter, if allowed to tell.
+func collectBitLocker(ctx context.Context, q Querier, byLetter map[string]Volume) error {
+	var v []struct {
+		DriveLetter      string
+		ProtectionStatus uint64
+	}
+
+	if err := query(ctx, q,
+		"SELECT DriveLetter, ProtectionStatus FROM Win32_EncryptableVolume",
+		&v, nil, bitLockerNamespace); err != nil {
+		return warn(fmt.Errorf("BitLocker: %w", err))
+	}
+
+	for _, e := range v {
+		if vol, ok := byLetter[e.DriveLetter]; ok {
+			vol.BitLocker = bitLockerStatus[e.ProtectionStatus]
+			if vol.BitLocker == "" {
+				vol.BitLocker = "N/A"
+			}
+			byLetter[e.DriveLetter] = vol
+		}
+	}
+
+	return nil
+}
+
+// wmiObjectID
+// The DeviceID of a WMI object path, unescaped, e.g., \\.\PHYSICALDRIVE0.
+func wmiObjectID(path string) string {
+	m := wmiDeviceID.FindStringSubmatch(path)
+	if m == nil {
+		return ""
+	}
+	return strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(m[1])
+}